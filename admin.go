@@ -0,0 +1,133 @@
+package fasthttpprometheus
+
+import (
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+)
+
+// adminReport is the JSON document served on the AdminPath.
+type adminReport struct {
+	Enabled bool `json:"enabled"`
+	// Endpoints is the number of distinct endpoint label values recorded
+	// by the request counter.
+	Endpoints int `json:"endpoints"`
+	// EndpointsOverflowed reports whether MaxEndpoints was reached and
+	// endpoints are recorded as "other".
+	EndpointsOverflowed bool `json:"endpoints_overflowed"`
+	// Series is the number of series of each of the middleware's metric
+	// families.
+	Series map[string]int `json:"series"`
+	Config adminConfig    `json:"config"`
+}
+
+type adminConfig struct {
+	Namespace       string            `json:"namespace,omitempty"`
+	Subsystem       string            `json:"subsystem,omitempty"`
+	ConstLabels     map[string]string `json:"const_labels,omitempty"`
+	MetricsPaths    []string          `json:"metrics_paths"`
+	Labels          []string          `json:"labels"`
+	DurationUnit    string            `json:"duration_unit"`
+	Buckets         []float64         `json:"buckets"`
+	EndpointBuckets []string          `json:"endpoint_buckets,omitempty"`
+	StatusClass     bool              `json:"status_class"`
+	RouteTemplates  bool              `json:"route_templates"`
+	MaxEndpoints    int64             `json:"max_endpoints,omitempty"`
+	QueryParams     []string          `json:"query_params,omitempty"`
+	PathGroups      int               `json:"path_groups,omitempty"`
+	SeriesTTL       string            `json:"series_ttl,omitempty"`
+	Observers       int               `json:"observers,omitempty"`
+	SampleRate      float64           `json:"sample_rate,omitempty"`
+}
+
+// AdminPath is an option which serves a JSON report on path, protected like the metrics, with the number of endpoint label
+// values, the number of series of each metric family and the configuration of the middleware, to debug cardinality
+// and misconfiguration
+func AdminPath(path string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.adminPath = path
+	}
+}
+
+// AdminJSON returns the report served on the AdminPath.
+func (p *Prometheus) AdminJSON() ([]byte, error) {
+	mfs, err := p.own.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	report := adminReport{
+		Enabled: p.Enabled(),
+		Series:  make(map[string]int, len(mfs)),
+		Config:  p.adminConfig(),
+	}
+
+	requests := p.fqName("requests_total")
+	for _, mf := range mfs {
+		report.Series[mf.GetName()] = len(mf.GetMetric())
+		if mf.GetName() != requests {
+			continue
+		}
+
+		endpoints := map[string]bool{}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "endpoint" {
+					endpoints[l.GetValue()] = true
+				}
+			}
+		}
+		report.Endpoints = len(endpoints)
+	}
+
+	if l := p.endpointLimiter; l != nil {
+		report.EndpointsOverflowed = atomic.LoadInt64(&l.n) >= l.max
+	}
+
+	return json.Marshal(report)
+}
+
+func (p *Prometheus) adminConfig() adminConfig {
+	c := adminConfig{
+		Namespace:      p.namespace,
+		Subsystem:      p.subsystem,
+		ConstLabels:    p.constLabels,
+		MetricsPaths:   p.metricsPaths(),
+		Labels:         p.labelNames(),
+		DurationUnit:   p.durationUnit.String(),
+		Buckets:        p.requestDurationBuckets(),
+		StatusClass:    p.statusClass,
+		RouteTemplates: p.routeTemplates,
+		QueryParams:    p.queryParams,
+		PathGroups:     len(p.pathGroups),
+		Observers:      len(p.observers),
+		SampleRate:     p.sampleRate,
+	}
+
+	for endpoint := range p.endpointBuckets {
+		c.EndpointBuckets = append(c.EndpointBuckets, endpoint)
+	}
+	sort.Strings(c.EndpointBuckets)
+
+	if p.endpointLimiter != nil {
+		c.MaxEndpoints = p.endpointLimiter.max
+	}
+	if p.expiry != nil {
+		c.SeriesTTL = p.expiry.ttl.String()
+	}
+
+	return c
+}
+
+func (p *Prometheus) adminHandler(ctx *fasthttp.RequestCtx) {
+	b, err := p.AdminJSON()
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetBody(b)
+}
@@ -0,0 +1,71 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+type allowlist struct {
+	cidrs  []string
+	nets   []*net.IPNet
+	denied prometheus.Counter
+}
+
+// MetricsAllowCIDRs is an option which restricts the metrics handler to clients within the given CIDRs
+func MetricsAllowCIDRs(cidrs ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.allowlist = &allowlist{cidrs: cidrs}
+	}
+}
+
+// parse parses the configured CIDRs.
+func (a *allowlist) parse() error {
+	a.nets = a.nets[:0]
+	for _, cidr := range a.cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("fasthttpprometheus: invalid metrics CIDR: %w", err)
+		}
+		a.nets = append(a.nets, n)
+	}
+	return nil
+}
+
+func (a *allowlist) allowed(ip net.IP) bool {
+	for _, n := range a.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap returns a handler answering 403 to clients outside of the allowlist.
+func (a *allowlist) wrap(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !a.allowed(ctx.RemoteIP()) {
+			a.denied.Inc()
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusForbidden), fasthttp.StatusForbidden)
+			return
+		}
+
+		h(ctx)
+	}
+}
+
+func (p *Prometheus) allowlistCollector() prometheus.Collector {
+	p.allowlist.denied = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("metrics_scrape_denied_total"),
+			Help:        "The metrics scrapes denied as coming from outside of the allowed CIDRs.",
+		},
+	)
+
+	return p.allowlist.denied
+}
@@ -0,0 +1,72 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type apdex struct {
+	threshold time.Duration
+
+	counter *prometheus.CounterVec
+}
+
+// Apdex is an option which counts the requests in apdex_requests_total by endpoint and zone: satisfied when served
+// within threshold, tolerating within four times threshold, and frustrated otherwise or when failing with a 5xx code.
+// The Apdex score of an endpoint is (satisfied + tolerating / 2) / total
+func Apdex(threshold time.Duration) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.apdex = &apdex{threshold: threshold}
+	}
+}
+
+// zone returns the Apdex zone of the request of stats.
+func (a *apdex) zone(stats RequestStats) string {
+	switch {
+	case stats.Code >= 500:
+		return "frustrated"
+	case stats.Duration <= a.threshold:
+		return "satisfied"
+	case stats.Duration <= 4*a.threshold:
+		return "tolerating"
+	}
+	return "frustrated"
+}
+
+// observeApdex counts the request of stats in its Apdex zone, with the Apdex
+// option.
+func (p *Prometheus) observeApdex(stats RequestStats) {
+	a := p.apdex
+	if a == nil {
+		return
+	}
+
+	zone := a.zone(stats)
+	a.counter.WithLabelValues(stats.Endpoint, zone).Inc()
+	p.trackSeries("apdex_requests_total", stats.Endpoint, zone)
+}
+
+// validate checks the Apdex threshold.
+func (a *apdex) validate() error {
+	if a != nil && a.threshold <= 0 {
+		return fmt.Errorf("fasthttpprometheus: Apdex threshold %v is not positive", a.threshold)
+	}
+	return nil
+}
+
+func (p *Prometheus) apdexCollector() prometheus.Collector {
+	p.apdex.counter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("apdex_requests_total"),
+			Help:        "The HTTP requests by Apdex zone.",
+		},
+		[]string{"endpoint", "zone"},
+	)
+
+	return p.apdex.counter
+}
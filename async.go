@@ -0,0 +1,213 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// asyncObservations queues the stats of the requests in a bounded lock-free
+// ring, drained by a single worker recording them in the collectors.
+type asyncObservations struct {
+	ring    *observationRing
+	wake    chan struct{}
+	dropped prometheus.Counter
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	// stopped is set to 1 by stopAsync before stopping the worker. The
+	// stats queued by the requests seeing it are drained by the requests
+	// themselves, one at a time under lateMu.
+	stopped int32
+	lateMu  sync.Mutex
+}
+
+// AsyncObservations is an option which moves recording the stats of the requests in the collectors and Observers off
+// the request path: they are queued in a lock-free ring of size entries, rounded up to a power of two, and recorded by a
+// background worker. When the ring is full, the stats are dropped and counted in async_observations_dropped_total.
+// After Shutdown, the stats are recorded on the request path. The OnRequestEnd functions and SlowRequestThreshold, which
+// are given the request, are still called on the request path
+func AsyncObservations(size int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if size < 1 {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: AsyncObservations size %d is not positive", size))
+			return
+		}
+		p.async = &asyncObservations{ring: newObservationRing(size), wake: make(chan struct{}, 1)}
+	}
+}
+
+func (p *Prometheus) asyncCollector() prometheus.Collector {
+	p.async.dropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("async_observations_dropped_total"),
+			Help:        "The request observations dropped because the AsyncObservations queue was full.",
+		},
+	)
+
+	return p.async.dropped
+}
+
+// observeAsync queues stats to be recorded with the collectors of p, or
+// records it right away without AsyncObservations.
+func (p *Prometheus) observeAsync(stats RequestStats) {
+	a := p.async
+	if a == nil {
+		p.observe(stats)
+		return
+	}
+
+	if atomic.LoadInt32(&a.stopped) == 1 {
+		p.observe(stats)
+		return
+	}
+
+	if !a.ring.push(p, stats) {
+		a.dropped.Inc()
+		return
+	}
+
+	// The worker drains the ring after stopAsync sets stopped, so it records
+	// the stats pushed before. If stopped is set now, it may have exited
+	// first.
+	if atomic.LoadInt32(&a.stopped) == 1 {
+		a.lateMu.Lock()
+		<-a.done
+		a.drain()
+		a.lateMu.Unlock()
+		return
+	}
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// startAsync starts the worker of AsyncObservations, if configured.
+func (p *Prometheus) startAsync() {
+	a := p.async
+	if a == nil {
+		return
+	}
+
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+
+	// The wake token is sent after the stats are published in the ring and
+	// received before draining it, so no stats wait for a later request.
+	go func() {
+		defer close(a.done)
+
+		for {
+			a.drain()
+
+			select {
+			case <-a.wake:
+			case <-a.stop:
+				a.drain()
+				return
+			}
+		}
+	}()
+}
+
+// stopAsync stops the worker of AsyncObservations after recording the
+// queued observations. The later ones are recorded synchronously.
+func (p *Prometheus) stopAsync() {
+	a := p.async
+	if a == nil {
+		return
+	}
+
+	a.stopOnce.Do(func() {
+		atomic.StoreInt32(&a.stopped, 1)
+		close(a.stop)
+	})
+	<-a.done
+}
+
+// drain records the queued observations, each with the collectors of the
+// Prometheus, possibly a scoped child, that queued it.
+func (a *asyncObservations) drain() {
+	for {
+		p, stats, ok := a.ring.pop()
+		if !ok {
+			return
+		}
+		p.observe(stats)
+	}
+}
+
+// observationRing is a bounded multi-producer single-consumer queue, after
+// Dmitry Vyukov's bounded MPMC queue: each slot has a sequence number telling
+// the producers and the consumer whether it is free or holds an entry.
+type observationRing struct {
+	mask  uint64
+	slots []observationSlot
+	head  uint64 // next position to push, shared by the producers
+	tail  uint64 // next position to pop, owned by the consumer
+}
+
+type observationSlot struct {
+	seq   uint64
+	p     *Prometheus
+	stats RequestStats
+}
+
+func newObservationRing(size int) *observationRing {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+
+	r := &observationRing{mask: uint64(n - 1), slots: make([]observationSlot, n)}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+	}
+	return r
+}
+
+// push queues stats for p, reporting false if the ring is full.
+func (r *observationRing) push(p *Prometheus, stats RequestStats) bool {
+	for {
+		pos := atomic.LoadUint64(&r.head)
+		slot := &r.slots[pos&r.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+				slot.p = p
+				slot.stats = stats
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+	}
+}
+
+// pop dequeues the oldest entry, reporting false if there is none. It must
+// only be called by a single consumer.
+func (r *observationRing) pop() (*Prometheus, RequestStats, bool) {
+	pos := r.tail
+	slot := &r.slots[pos&r.mask]
+	if atomic.LoadUint64(&slot.seq) != pos+1 {
+		return nil, RequestStats{}, false
+	}
+
+	p, stats := slot.p, slot.stats
+	slot.p, slot.stats = nil, RequestStats{}
+	atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+	r.tail++
+	return p, stats, true
+}
@@ -0,0 +1,66 @@
+package fasthttpprometheus
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"github.com/valyala/fasthttp"
+)
+
+type basicAuth struct {
+	user, pass [sha256.Size]byte
+}
+
+// MetricsBasicAuth is an option which protects the metrics handler with HTTP basic authentication
+func MetricsBasicAuth(user, pass string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.basicAuth = &basicAuth{
+			user: sha256.Sum256([]byte(user)),
+			pass: sha256.Sum256([]byte(pass)),
+		}
+	}
+}
+
+// authorized reports whether ctx carries the expected credentials. The
+// credentials are compared as hashes in constant time, so neither their
+// contents nor their lengths leak through timing.
+func (a *basicAuth) authorized(ctx *fasthttp.RequestCtx) bool {
+	auth := ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)
+	const prefix = "Basic "
+	if !bytes.HasPrefix(auth, []byte(prefix)) {
+		return false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(auth[len(prefix):]))
+	if err != nil {
+		return false
+	}
+
+	user, pass, ok := bytes.Cut(decoded, []byte(":"))
+	if !ok {
+		return false
+	}
+
+	userHash := sha256.Sum256(user)
+	passHash := sha256.Sum256(pass)
+
+	userOK := subtle.ConstantTimeCompare(userHash[:], a.user[:])
+	passOK := subtle.ConstantTimeCompare(passHash[:], a.pass[:])
+
+	return userOK&passOK == 1
+}
+
+// wrap returns a handler answering 401 to requests without the expected credentials.
+func (a *basicAuth) wrap(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !a.authorized(ctx) {
+			ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, `Basic realm="metrics"`)
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusUnauthorized), fasthttp.StatusUnauthorized)
+			return
+		}
+
+		h(ctx)
+	}
+}
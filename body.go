@@ -0,0 +1,100 @@
+package fasthttpprometheus
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// bodyReadKey is the user value key of the bodyRead of a request.
+const bodyReadKey = "fasthttpprometheus.bodyRead"
+
+// bodyRead accumulates the time a handler spent reading the request body.
+type bodyRead struct {
+	d time.Duration
+}
+
+// RequestBodyReadDuration is an option which enables the request_body_read_duration_seconds histogram of the time
+// handlers spend reading the request body with RequestBody or RequestBodyStream, by method and endpoint. This time
+// is only significant with fasthttp.Server.StreamRequestBody, otherwise the body is read before the handler is called
+func RequestBodyReadDuration() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.bodyReadDuration = true
+	}
+}
+
+// RequestBody is the counterpart of ctx.PostBody measuring the time spent reading the request body
+func (p *Prometheus) RequestBody(ctx *fasthttp.RequestCtx) []byte {
+	start := time.Now()
+	body := ctx.PostBody()
+	bodyReadOf(ctx).d += time.Since(start)
+	return body
+}
+
+// RequestBodyStream is the counterpart of ctx.RequestBodyStream measuring the time spent reading from the returned
+// stream
+func (p *Prometheus) RequestBodyStream(ctx *fasthttp.RequestCtx) io.Reader {
+	return &timedReader{r: ctx.RequestBodyStream(), read: bodyReadOf(ctx)}
+}
+
+// bodyReadOf returns the bodyRead of the request in ctx, creating it if
+// needed.
+func bodyReadOf(ctx *fasthttp.RequestCtx) *bodyRead {
+	br, _ := ctx.UserValue(bodyReadKey).(*bodyRead)
+	if br == nil {
+		br = &bodyRead{}
+		ctx.SetUserValue(bodyReadKey, br)
+	}
+	return br
+}
+
+// bodyReadDuration returns the time the handler spent reading the request
+// body in ctx.
+func bodyReadDuration(ctx *fasthttp.RequestCtx) time.Duration {
+	if br, ok := ctx.UserValue(bodyReadKey).(*bodyRead); ok {
+		return br.d
+	}
+	return 0
+}
+
+// timedReader accumulates the time spent in its Read calls in read.
+type timedReader struct {
+	r    io.Reader
+	read *bodyRead
+}
+
+func (t *timedReader) Read(b []byte) (int, error) {
+	start := time.Now()
+	n, err := t.r.Read(b)
+	t.read.d += time.Since(start)
+	return n, err
+}
+
+// observeBodyReadDuration observes the body read duration in stats, if the
+// request body was read.
+func (p *Prometheus) observeBodyReadDuration(stats RequestStats) {
+	if p.bodyReadDur == nil || stats.BodyReadDuration == 0 {
+		return
+	}
+
+	p.bodyReadDur.WithLabelValues(stats.Method, stats.Endpoint).Observe(p.durationValue(stats.BodyReadDuration))
+	p.trackSeries("request_body_read_duration_seconds", stats.Method, stats.Endpoint)
+}
+
+func (p *Prometheus) bodyReadDurationCollector() prometheus.Collector {
+	p.bodyReadDur = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("request_body_read_duration_seconds"),
+			Help:        "The time spent reading the HTTP request body in " + p.durationUnit.String() + ".",
+			Buckets:     p.requestDurationBuckets(),
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	return p.bodyReadDur
+}
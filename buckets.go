@@ -0,0 +1,84 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExponentialBuckets is an option which sets count request duration buckets, the first being start and each of the
+// others being factor times the previous one
+func ExponentialBuckets(start, factor float64, count int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.buckets = p.exponentialBuckets("ExponentialBuckets", start, factor, count)
+	}
+}
+
+// LinearBuckets is an option which sets count request duration buckets, the first being start, which may be zero, and
+// each of the others being width more than the previous one
+func LinearBuckets(start, width float64, count int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.buckets = p.linearBuckets("LinearBuckets", start, width, count)
+	}
+}
+
+// ExponentialSizeBuckets is an option which sets count byte buckets of the size histograms given no buckets, the
+// first being start and each of the others being factor times the previous one
+func ExponentialSizeBuckets(start, factor float64, count int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.sizeBuckets = p.exponentialBuckets("ExponentialSizeBuckets", start, factor, count)
+	}
+}
+
+// LinearSizeBuckets is an option which sets count byte buckets of the size histograms given no buckets, the first
+// being start, which may be zero, and each of the others being width more than the previous one
+func LinearSizeBuckets(start, width float64, count int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.sizeBuckets = p.linearBuckets("LinearSizeBuckets", start, width, count)
+	}
+}
+
+// exponentialBuckets returns the buckets of the option named option,
+// recording an error for invalid arguments instead of panicking like
+// prometheus.ExponentialBuckets.
+func (p *Prometheus) exponentialBuckets(option string, start, factor float64, count int) []float64 {
+	switch {
+	case count < 1:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s count %d is not positive", option, count))
+		return nil
+	case start <= 0:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s start %v is not positive", option, start))
+		return nil
+	case factor <= 1:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s factor %v is not greater than 1", option, factor))
+		return nil
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start * math.Pow(factor, float64(i))
+	}
+	return buckets
+}
+
+// linearBuckets returns the buckets of the option named option, recording an
+// error for invalid arguments instead of panicking like
+// prometheus.LinearBuckets.
+func (p *Prometheus) linearBuckets(option string, start, width float64, count int) []float64 {
+	switch {
+	case count < 1:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s count %d is not positive", option, count))
+		return nil
+	case start < 0:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s start %v is negative", option, start))
+		return nil
+	case width <= 0:
+		p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: %s width %v is not positive", option, width))
+		return nil
+	}
+
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start + width*float64(i)
+	}
+	return buckets
+}
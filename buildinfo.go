@@ -0,0 +1,36 @@
+package fasthttpprometheus
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type buildInfo struct {
+	version, commit, date string
+}
+
+// BuildInfo is an option which registers the build_info gauge, always 1, with the version, commit and date labels set to
+// the given values and goversion to the Go version the binary was built with, to correlate the metrics with deploys
+func BuildInfo(version, commit, date string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.buildInfo = &buildInfo{version: version, commit: commit, date: date}
+	}
+}
+
+func (p *Prometheus) buildInfoCollector() prometheus.Collector {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: p.namespace,
+		Subsystem: p.subsystem,
+		ConstLabels: p.constLabelsWith(prometheus.Labels{
+			"version":   p.buildInfo.version,
+			"commit":    p.buildInfo.commit,
+			"date":      p.buildInfo.date,
+			"goversion": runtime.Version(),
+		}),
+		Name: p.name("build_info"),
+		Help: "A metric with a constant '1' value labeled by the version, commit and date of the build.",
+	})
+	g.Set(1)
+	return g
+}
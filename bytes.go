@@ -0,0 +1,82 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type byteCounters struct {
+	byEndpoint bool
+
+	in, out *prometheus.CounterVec
+}
+
+// ByteCounters is an option which adds the request_bytes_total and response_bytes_total counters of the bytes received
+// and sent, whose rate is the bandwidth used
+func ByteCounters(options ...func(*byteCounters)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		b := &byteCounters{}
+		for _, option := range options {
+			option(b)
+		}
+		p.bytes = b
+	}
+}
+
+// ByEndpoint is a ByteCounters option which partitions the counters by endpoint
+func ByEndpoint() func(*byteCounters) {
+	return func(b *byteCounters) {
+		b.byEndpoint = true
+	}
+}
+
+// countBytes adds the sizes in stats to the byte counters, with the
+// ByteCounters option.
+func (p *Prometheus) countBytes(stats RequestStats) {
+	b := p.bytes
+	if b == nil {
+		return
+	}
+
+	var lvs []string
+	if b.byEndpoint {
+		lvs = []string{stats.Endpoint}
+		p.trackSeries("request_bytes_total", lvs...)
+		p.trackSeries("response_bytes_total", lvs...)
+	}
+
+	b.in.WithLabelValues(lvs...).Add(float64(stats.RequestSize))
+	b.out.WithLabelValues(lvs...).Add(float64(stats.ResponseSize))
+}
+
+func (p *Prometheus) byteCollectors() []prometheus.Collector {
+	b := p.bytes
+
+	var labels []string
+	if b.byEndpoint {
+		labels = []string{"endpoint"}
+	}
+
+	b.in = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("request_bytes_total"),
+			Help:        "The approximate HTTP request bytes received.",
+		},
+		labels,
+	)
+
+	b.out = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("response_bytes_total"),
+			Help:        "The HTTP response body bytes sent.",
+		},
+		labels,
+	)
+
+	return []prometheus.Collector{b.in, b.out}
+}
@@ -0,0 +1,98 @@
+package fasthttpprometheus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// childKey identifies the children of the request counter and duration
+// histogram for requests without extra labels.
+type childKey struct {
+	code, method, endpoint, mount string
+}
+
+// requestChildren are the resolved children of the request counter and
+// duration histogram for a childKey, with the SeriesTTL entry of their series.
+type requestChildren struct {
+	count    prometheus.Counter
+	duration prometheus.Observer
+	expiry   *expiryEntry
+}
+
+// maxCachedChildren bounds the number of cached requestChildren. Once it is
+// reached, the requests of label values not cached yet resolve their
+// children with WithLabelValues.
+const maxCachedChildren = 4096
+
+// childCache caches the requestChildren so the requests do not hash their
+// label values with WithLabelValues. It is shared with the scoped children,
+// hence the mount in the key, and must be cleared when series are deleted.
+type childCache struct {
+	mu       sync.RWMutex
+	children map[childKey]*requestChildren
+	// gen is incremented by clear, so children resolved before series were
+	// deleted, which may no longer be part of the vectors, are not cached.
+	gen uint64
+}
+
+func newChildCache() *childCache {
+	return &childCache{children: map[childKey]*requestChildren{}}
+}
+
+// requestChildren returns the children of the request counter and duration
+// histogram for the label values lvs computes.
+func (p *Prometheus) requestChildren(code, method, endpoint string, lvs func() []string) *requestChildren {
+	if len(p.extraLabels) > 0 {
+		return p.resolveChildren(endpoint, lvs())
+	}
+
+	key := childKey{code: code, method: method, endpoint: endpoint, mount: p.mount}
+	c := p.children
+
+	c.mu.RLock()
+	children, ok := c.children[key]
+	gen := c.gen
+	c.mu.RUnlock()
+	if ok {
+		return children
+	}
+
+	children = p.resolveChildren(endpoint, lvs())
+	c.mu.Lock()
+	if c.gen == gen && len(c.children) < maxCachedChildren {
+		c.children[key] = children
+	}
+	c.mu.Unlock()
+	return children
+}
+
+// resolveChildren resolves the children for the label values lvs, tracking
+// their series for SeriesCount and SeriesTTL, so the cached children do it
+// once rather than on every request.
+func (p *Prometheus) resolveChildren(endpoint string, lvs []string) *requestChildren {
+	p.trackSeries("requests_total", lvs...)
+	p.trackSeries("request_duration_seconds", lvs...)
+
+	return &requestChildren{
+		count:    p.reqCnt.WithLabelValues(lvs...),
+		duration: p.durationVec(endpoint).WithLabelValues(lvs...),
+		expiry:   p.touchSeries(lvs),
+	}
+}
+
+// touch records that the series of c were just observed, for SeriesTTL.
+func (c *requestChildren) touch() {
+	if c.expiry != nil {
+		c.expiry.touch(time.Now().UnixNano())
+	}
+}
+
+// clear drops the cached children, after their series were deleted.
+func (c *childCache) clear() {
+	c.mu.Lock()
+	c.children = map[childKey]*requestChildren{}
+	c.gen++
+	c.mu.Unlock()
+}
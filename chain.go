@@ -0,0 +1,33 @@
+package fasthttpprometheus
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// Middleware wraps a fasthttp.RequestHandler, e.g. to authenticate or log
+// requests.
+type Middleware func(fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Use adds middlewares around the handlers instrumented afterwards, the first
+// one being the outermost. The requests are observed outside of them, so
+// their time is included in the request duration, unless the MetricsInnermost
+// option is set.
+func (p *Prometheus) Use(mw ...Middleware) {
+	p.middlewares = append(p.middlewares, mw...)
+}
+
+// MetricsInnermost is an option which observes the requests inside the middlewares added with Use, so they are
+// excluded from the request metrics, e.g. the requests rejected by an authentication middleware
+func MetricsInnermost() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.metricsInnermost = true
+	}
+}
+
+// chain returns h wrapped in the middlewares added with Use.
+func (p *Prometheus) chain(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		h = p.middlewares[i](h)
+	}
+	return h
+}
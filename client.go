@@ -0,0 +1,135 @@
+package fasthttpprometheus
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// Doer is implemented by the fasthttp clients, such as *fasthttp.Client,
+// *fasthttp.HostClient and *fasthttp.LBClient.
+type Doer interface {
+	Do(req *fasthttp.Request, resp *fasthttp.Response) error
+}
+
+// DoerFunc is an adapter allowing the use of ordinary functions as Doer.
+type DoerFunc func(req *fasthttp.Request, resp *fasthttp.Response) error
+
+// Do calls f(req, resp).
+func (f DoerFunc) Do(req *fasthttp.Request, resp *fasthttp.Response) error {
+	return f(req, resp)
+}
+
+// clientMetrics are the collectors of the clients instrumented with
+// WrapClient, registered with the first of them.
+type clientMetrics struct {
+	register sync.Once
+
+	reqCnt *prometheus.CounterVec
+	reqDur *prometheus.HistogramVec
+	errs   *prometheus.CounterVec
+}
+
+// WrapClient returns a Doer observing the requests sent with d in client_requests_total and
+// client_request_duration_seconds, by destination host and code, and counting their errors in
+// client_request_errors_total, by host and error. The collectors are registered with the first wrapped client
+func (p *Prometheus) WrapClient(d Doer) Doer {
+	m := p.clientMetrics()
+
+	return DoerFunc(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		host := string(req.URI().Host())
+
+		start := time.Now()
+		err := d.Do(req, resp)
+		p.observeClient(m, host, resp, err, time.Since(start))
+		return err
+	})
+}
+
+// clientMetrics returns the client collectors, registering them on first use.
+func (p *Prometheus) clientMetrics() *clientMetrics {
+	m := p.client
+	m.register.Do(func() {
+		p.registerCollectors(m.reqCnt, m.reqDur, m.errs)
+	})
+	return m
+}
+
+// observeClient observes a request to host which took elapsed and got resp
+// or err.
+func (p *Prometheus) observeClient(m *clientMetrics, host string, resp *fasthttp.Response, err error, elapsed time.Duration) {
+	if err != nil {
+		m.errs.WithLabelValues(host, clientErrorType(err)).Inc()
+		return
+	}
+
+	code := p.statusLabel(resp.StatusCode())
+	m.reqCnt.WithLabelValues(host, code).Inc()
+	m.reqDur.WithLabelValues(host, code).Observe(p.durationValue(elapsed))
+}
+
+// clientErrorType returns the error label of client_request_errors_total for
+// an error returned by a fasthttp client.
+func clientErrorType(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, fasthttp.ErrNoFreeConns):
+		return "no_free_conns"
+	case errors.Is(err, fasthttp.ErrDialTimeout):
+		return "dial_timeout"
+	case errors.Is(err, fasthttp.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, fasthttp.ErrConnectionClosed):
+		return "connection_closed"
+	case errors.Is(err, fasthttp.ErrTooManyRedirects):
+		return "too_many_redirects"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return "other"
+}
+
+// clientCollectors creates the collectors of WrapClient, which are not
+// registered until it is called.
+func (p *Prometheus) clientCollectors() {
+	p.client = &clientMetrics{
+		reqCnt: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("client_requests_total"),
+				Help:        "The HTTP request counts sent by the client.",
+			},
+			[]string{"host", "code"},
+		),
+		reqDur: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("client_request_duration_seconds"),
+				Help:        "The HTTP request duration of the client in " + p.durationUnit.String() + ".",
+				Buckets:     p.requestDurationBuckets(),
+			},
+			[]string{"host", "code"},
+		),
+		errs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("client_request_errors_total"),
+				Help:        "The HTTP requests of the client failing without response by error.",
+			},
+			[]string{"host", "error"},
+		),
+	}
+}
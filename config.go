@@ -0,0 +1,424 @@
+package fasthttpprometheus
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds the options of the middleware which can be set from a
+// configuration file. The zero value of each field leaves the option unset.
+// Options taking functions, like Skip or ExtraLabels, can be passed to
+// NewFromConfig along with the Config.
+type Config struct {
+	MetricsPaths     []string          `yaml:"metrics_paths,omitempty" json:"metrics_paths,omitempty"`
+	MetricsJSONPath  string            `yaml:"metrics_json_path,omitempty" json:"metrics_json_path,omitempty"`
+	AdminPath        string            `yaml:"admin_path,omitempty" json:"admin_path,omitempty"`
+	MetricsInnermost bool              `yaml:"metrics_innermost,omitempty" json:"metrics_innermost,omitempty"`
+	Namespace        string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Subsystem        string            `yaml:"subsystem,omitempty" json:"subsystem,omitempty"`
+	ConstLabels      map[string]string `yaml:"const_labels,omitempty" json:"const_labels,omitempty"`
+	OpenMetrics      bool              `yaml:"open_metrics,omitempty" json:"open_metrics,omitempty"`
+	Expvar           string            `yaml:"expvar,omitempty" json:"expvar,omitempty"`
+
+	StandardCollectors bool `yaml:"standard_collectors,omitempty" json:"standard_collectors,omitempty"`
+	Uptime             bool `yaml:"uptime,omitempty" json:"uptime,omitempty"`
+
+	// WorkerLabel is the value of the worker label, "pid" for the process id.
+	WorkerLabel string `yaml:"worker_label,omitempty" json:"worker_label,omitempty"`
+
+	// DurationUnit is "seconds" or "milliseconds".
+	DurationUnit    string               `yaml:"duration_unit,omitempty" json:"duration_unit,omitempty"`
+	Buckets         []float64            `yaml:"buckets,omitempty" json:"buckets,omitempty"`
+	EndpointBuckets map[string][]float64 `yaml:"endpoint_buckets,omitempty" json:"endpoint_buckets,omitempty"`
+	SampleRate      float64              `yaml:"sample_rate,omitempty" json:"sample_rate,omitempty"`
+
+	// AsyncObservations is the size of the AsyncObservations queue.
+	AsyncObservations int            `yaml:"async_observations,omitempty" json:"async_observations,omitempty"`
+	Summary           *SummaryConfig `yaml:"summary,omitempty" json:"summary,omitempty"`
+	NativeHistogram   *NativeConfig  `yaml:"native_histogram,omitempty" json:"native_histogram,omitempty"`
+
+	StatusClass      bool              `yaml:"status_class,omitempty" json:"status_class,omitempty"`
+	ContentTypeLabel bool              `yaml:"content_type_label,omitempty" json:"content_type_label,omitempty"`
+	RouteTemplates   bool              `yaml:"route_templates,omitempty" json:"route_templates,omitempty"`
+	MountLabel       bool              `yaml:"mount_label,omitempty" json:"mount_label,omitempty"`
+	ConnectionLabel  bool              `yaml:"connection_label,omitempty" json:"connection_label,omitempty"`
+	HostLabel        int               `yaml:"host_label,omitempty" json:"host_label,omitempty"`
+	TenantHeader     string            `yaml:"tenant_header,omitempty" json:"tenant_header,omitempty"`
+	QueryParams      []string          `yaml:"query_params,omitempty" json:"query_params,omitempty"`
+	MaxEndpoints     int               `yaml:"max_endpoints,omitempty" json:"max_endpoints,omitempty"`
+	PathGroups       []PathGroupConfig `yaml:"path_groups,omitempty" json:"path_groups,omitempty"`
+
+	RequestSizeHistogram    *SizeConfig `yaml:"request_size_histogram,omitempty" json:"request_size_histogram,omitempty"`
+	ResponseSizeHistogram   *SizeConfig `yaml:"response_size_histogram,omitempty" json:"response_size_histogram,omitempty"`
+	ByteCounters            bool        `yaml:"byte_counters,omitempty" json:"byte_counters,omitempty"`
+	ByteCountersByEndpoint  bool        `yaml:"byte_counters_by_endpoint,omitempty" json:"byte_counters_by_endpoint,omitempty"`
+	TimeToFirstByte         bool        `yaml:"time_to_first_byte,omitempty" json:"time_to_first_byte,omitempty"`
+	RequestReadDuration     bool        `yaml:"request_read_duration,omitempty" json:"request_read_duration,omitempty"`
+	RequestBodyReadDuration bool        `yaml:"request_body_read_duration,omitempty" json:"request_body_read_duration,omitempty"`
+	QueueDurationHeader     string      `yaml:"queue_duration_header,omitempty" json:"queue_duration_header,omitempty"`
+	ConnectionRequests      bool        `yaml:"connection_requests,omitempty" json:"connection_requests,omitempty"`
+	UnmatchedRequests       bool        `yaml:"unmatched_requests,omitempty" json:"unmatched_requests,omitempty"`
+	RecoverPanics           bool        `yaml:"recover_panics,omitempty" json:"recover_panics,omitempty"`
+	InFlightByEndpoint      bool        `yaml:"in_flight_by_endpoint,omitempty" json:"in_flight_by_endpoint,omitempty"`
+	Websockets              bool        `yaml:"websockets,omitempty" json:"websockets,omitempty"`
+	StreamingEndpoints      []string    `yaml:"streaming_endpoints,omitempty" json:"streaming_endpoints,omitempty"`
+	TraceExemplars          bool        `yaml:"trace_exemplars,omitempty" json:"trace_exemplars,omitempty"`
+	DeadlineHeader          string      `yaml:"deadline_header,omitempty" json:"deadline_header,omitempty"`
+
+	SlowRequestThreshold model.Duration `yaml:"slow_request_threshold,omitempty" json:"slow_request_threshold,omitempty"`
+	Apdex                model.Duration `yaml:"apdex,omitempty" json:"apdex,omitempty"`
+	LatencySLO           *SLOConfig     `yaml:"latency_slo,omitempty" json:"latency_slo,omitempty"`
+
+	SeriesCount             bool           `yaml:"series_count,omitempty" json:"series_count,omitempty"`
+	SeriesTTL               model.Duration `yaml:"series_ttl,omitempty" json:"series_ttl,omitempty"`
+	PreInitializeCodes      []string       `yaml:"pre_initialize_codes,omitempty" json:"pre_initialize_codes,omitempty"`
+	PreInitializeMethods    []string       `yaml:"pre_initialize_methods,omitempty" json:"pre_initialize_methods,omitempty"`
+	PreInitializeEndpoints  []string       `yaml:"pre_initialize_endpoints,omitempty" json:"pre_initialize_endpoints,omitempty"`
+	PreInitializeRoutes     bool           `yaml:"pre_initialize_routes,omitempty" json:"pre_initialize_routes,omitempty"`
+	PreInitializeRouteCodes []string       `yaml:"pre_initialize_route_codes,omitempty" json:"pre_initialize_route_codes,omitempty"`
+
+	PushGateway       *PushConfig         `yaml:"push_gateway,omitempty" json:"push_gateway,omitempty"`
+	Multiprocess      *MultiprocessConfig `yaml:"multiprocess,omitempty" json:"multiprocess,omitempty"`
+	MetricsBasicAuth  *BasicAuthConfig    `yaml:"metrics_basic_auth,omitempty" json:"metrics_basic_auth,omitempty"`
+	MetricsAllowCIDRs []string            `yaml:"metrics_allow_cidrs,omitempty" json:"metrics_allow_cidrs,omitempty"`
+	MetricsTLS        *TLSConfig          `yaml:"metrics_tls,omitempty" json:"metrics_tls,omitempty"`
+}
+
+// SummaryConfig configures SummaryObjectives and SummaryWindow. The keys of
+// Objectives are the quantiles, e.g. "0.99".
+type SummaryConfig struct {
+	Objectives map[string]float64 `yaml:"objectives" json:"objectives"`
+	MaxAge     model.Duration     `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+	AgeBuckets uint32             `yaml:"age_buckets,omitempty" json:"age_buckets,omitempty"`
+}
+
+// NativeConfig configures NativeHistograms.
+type NativeConfig struct {
+	BucketFactor     float64        `yaml:"bucket_factor" json:"bucket_factor"`
+	ZeroThreshold    float64        `yaml:"zero_threshold,omitempty" json:"zero_threshold,omitempty"`
+	MaxBuckets       uint32         `yaml:"max_buckets,omitempty" json:"max_buckets,omitempty"`
+	MinResetDuration model.Duration `yaml:"min_reset_duration,omitempty" json:"min_reset_duration,omitempty"`
+}
+
+// PathGroupConfig configures a PathGroup, Pattern being a regular expression.
+type PathGroupConfig struct {
+	Pattern     string `yaml:"pattern" json:"pattern"`
+	Replacement string `yaml:"replacement" json:"replacement"`
+}
+
+// SizeConfig configures RequestSizeHistogram and ResponseSizeHistogram.
+type SizeConfig struct {
+	Buckets []float64 `yaml:"buckets,omitempty" json:"buckets,omitempty"`
+}
+
+// SLOConfig configures LatencySLO.
+type SLOConfig struct {
+	Target    model.Duration            `yaml:"target" json:"target"`
+	Endpoints map[string]model.Duration `yaml:"endpoints,omitempty" json:"endpoints,omitempty"`
+}
+
+// PushConfig configures PushGateway.
+type PushConfig struct {
+	URL      string         `yaml:"url" json:"url"`
+	Job      string         `yaml:"job" json:"job"`
+	Interval model.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// MultiprocessConfig configures Multiprocess.
+type MultiprocessConfig struct {
+	Dir      string         `yaml:"dir" json:"dir"`
+	Interval model.Duration `yaml:"interval" json:"interval"`
+}
+
+// BasicAuthConfig configures MetricsBasicAuth.
+type BasicAuthConfig struct {
+	User     string `yaml:"user" json:"user"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// TLSConfig configures MetricsTLS with the certificate and key in PEM files.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" json:"cert_file"`
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+}
+
+// LoadConfig reads the Config in the file at path, as JSON if its extension
+// is .json and as YAML otherwise. Unknown fields are rejected.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("fasthttpprometheus: reading config: %w", err)
+	}
+
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	} else {
+		err = yaml.UnmarshalStrict(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("fasthttpprometheus: parsing config %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// NewFromConfig creates the middleware with the options of c, followed by
+// options.
+func NewFromConfig(c Config, options ...func(*Prometheus)) (*Prometheus, error) {
+	return NewPrometheusWithError(append(c.Options(), options...)...)
+}
+
+// Options returns the options set by c. Invalid values are reported when
+// the middleware is created.
+func (c Config) Options() []func(*Prometheus) {
+	var options []func(*Prometheus)
+	add := func(option func(*Prometheus)) {
+		options = append(options, option)
+	}
+	fail := func(err error) {
+		add(func(p *Prometheus) {
+			p.optionErrs = append(p.optionErrs, err)
+		})
+	}
+
+	if len(c.MetricsPaths) > 0 {
+		add(MetricsPaths(c.MetricsPaths...))
+	}
+	if c.MetricsJSONPath != "" {
+		add(MetricsJSONPath(c.MetricsJSONPath))
+	}
+	if c.AdminPath != "" {
+		add(AdminPath(c.AdminPath))
+	}
+	if c.MetricsInnermost {
+		add(MetricsInnermost())
+	}
+	if c.Namespace != "" {
+		add(Namespace(c.Namespace))
+	}
+	if c.Subsystem != "" {
+		add(Subsystem(c.Subsystem))
+	}
+	if c.ConstLabels != nil {
+		add(ConstLabels(c.ConstLabels))
+	}
+	if c.OpenMetrics {
+		add(OpenMetrics())
+	}
+	if c.Expvar != "" {
+		add(Expvar(c.Expvar))
+	}
+	if c.StandardCollectors {
+		add(WithStandardCollectors())
+	}
+	if c.Uptime {
+		add(Uptime())
+	}
+	switch c.WorkerLabel {
+	case "":
+	case "pid":
+		add(WorkerLabel(""))
+	default:
+		add(WorkerLabel(c.WorkerLabel))
+	}
+
+	switch c.DurationUnit {
+	case "", Seconds.String():
+	case Milliseconds.String():
+		add(DurationUnit(Milliseconds))
+	default:
+		fail(fmt.Errorf("fasthttpprometheus: unknown duration unit %q", c.DurationUnit))
+	}
+	if c.Buckets != nil {
+		add(Buckets(c.Buckets))
+	}
+	if c.EndpointBuckets != nil {
+		add(EndpointBuckets(c.EndpointBuckets))
+	}
+	if c.SampleRate != 0 {
+		add(SampleRate(c.SampleRate))
+	}
+	if c.AsyncObservations != 0 {
+		add(AsyncObservations(c.AsyncObservations))
+	}
+	if s := c.Summary; s != nil {
+		objectives := make(map[float64]float64, len(s.Objectives))
+		for q, e := range s.Objectives {
+			quantile, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				fail(fmt.Errorf("fasthttpprometheus: invalid summary quantile %q", q))
+				continue
+			}
+			objectives[quantile] = e
+		}
+		add(SummaryObjectives(objectives))
+		if s.MaxAge != 0 || s.AgeBuckets != 0 {
+			add(SummaryWindow(time.Duration(s.MaxAge), s.AgeBuckets))
+		}
+	}
+	if n := c.NativeHistogram; n != nil {
+		var nativeOptions []func(*nativeHistogram)
+		if n.ZeroThreshold != 0 {
+			nativeOptions = append(nativeOptions, NativeZeroThreshold(n.ZeroThreshold))
+		}
+		if n.MaxBuckets != 0 {
+			nativeOptions = append(nativeOptions, NativeMaxBuckets(n.MaxBuckets, time.Duration(n.MinResetDuration)))
+		}
+		add(NativeHistograms(n.BucketFactor, nativeOptions...))
+	}
+
+	if c.StatusClass {
+		add(StatusClass())
+	}
+	if c.ContentTypeLabel {
+		add(ContentTypeLabel())
+	}
+	if c.RouteTemplates {
+		add(RouteTemplates())
+	}
+	if c.MountLabel {
+		add(MountLabel())
+	}
+	if c.ConnectionLabel {
+		add(ConnectionLabel())
+	}
+	if c.HostLabel != 0 {
+		add(HostLabel(c.HostLabel))
+	}
+	if c.TenantHeader != "" {
+		add(TenantHeader(c.TenantHeader))
+	}
+	if len(c.QueryParams) > 0 {
+		add(QueryParams(c.QueryParams...))
+	}
+	if c.MaxEndpoints != 0 {
+		add(MaxEndpoints(c.MaxEndpoints))
+	}
+	if len(c.PathGroups) > 0 {
+		groups := make([]PathGroup, 0, len(c.PathGroups))
+		for _, g := range c.PathGroups {
+			pattern, err := regexp.Compile(g.Pattern)
+			if err != nil {
+				fail(fmt.Errorf("fasthttpprometheus: invalid path group pattern %q: %w", g.Pattern, err))
+				continue
+			}
+			groups = append(groups, PathGroup{Pattern: pattern, Replacement: g.Replacement})
+		}
+		add(GroupPaths(groups))
+	}
+
+	if s := c.RequestSizeHistogram; s != nil {
+		add(RequestSizeHistogram(s.Buckets...))
+	}
+	if s := c.ResponseSizeHistogram; s != nil {
+		add(ResponseSizeHistogram(s.Buckets...))
+	}
+	if c.ByteCountersByEndpoint {
+		add(ByteCounters(ByEndpoint()))
+	} else if c.ByteCounters {
+		add(ByteCounters())
+	}
+	if c.TimeToFirstByte {
+		add(TimeToFirstByte())
+	}
+	if c.RequestReadDuration {
+		add(RequestReadDuration())
+	}
+	if c.RequestBodyReadDuration {
+		add(RequestBodyReadDuration())
+	}
+	if c.QueueDurationHeader != "" {
+		add(QueueDuration(c.QueueDurationHeader))
+	}
+	if c.ConnectionRequests {
+		add(ConnectionRequests())
+	}
+	if c.UnmatchedRequests {
+		add(UnmatchedRequests())
+	}
+	if c.RecoverPanics {
+		add(RecoverPanics())
+	}
+	if c.InFlightByEndpoint {
+		add(InFlightByEndpoint())
+	}
+	if c.Websockets {
+		add(Websockets())
+	}
+	if len(c.StreamingEndpoints) > 0 {
+		add(StreamingEndpoints(c.StreamingEndpoints...))
+	}
+	if c.TraceExemplars {
+		add(TraceExemplars())
+	}
+	if c.DeadlineHeader != "" {
+		add(DeadlineHeader(c.DeadlineHeader))
+	}
+
+	if c.SlowRequestThreshold != 0 {
+		add(SlowRequestThreshold(time.Duration(c.SlowRequestThreshold), nil))
+	}
+	if c.Apdex != 0 {
+		add(Apdex(time.Duration(c.Apdex)))
+	}
+	if s := c.LatencySLO; s != nil {
+		endpoints := make(map[string]time.Duration, len(s.Endpoints))
+		for endpoint, target := range s.Endpoints {
+			endpoints[endpoint] = time.Duration(target)
+		}
+		add(LatencySLO(time.Duration(s.Target), endpoints))
+	}
+
+	if c.SeriesCount {
+		add(SeriesCount())
+	}
+	if c.SeriesTTL != 0 {
+		add(SeriesTTL(time.Duration(c.SeriesTTL)))
+	}
+	if c.PreInitializeCodes != nil || c.PreInitializeMethods != nil {
+		add(PreInitialize(c.PreInitializeCodes, c.PreInitializeMethods))
+	}
+	if len(c.PreInitializeEndpoints) > 0 {
+		add(PreInitializeEndpoints(c.PreInitializeEndpoints...))
+	}
+	if c.PreInitializeRoutes {
+		add(PreInitializeRoutes(c.PreInitializeRouteCodes...))
+	}
+
+	if pg := c.PushGateway; pg != nil {
+		add(PushGateway(pg.URL, pg.Job, time.Duration(pg.Interval)))
+	}
+	if m := c.Multiprocess; m != nil {
+		add(Multiprocess(m.Dir, time.Duration(m.Interval)))
+	}
+	if a := c.MetricsBasicAuth; a != nil {
+		add(MetricsBasicAuth(a.User, a.Password))
+	}
+	if len(c.MetricsAllowCIDRs) > 0 {
+		add(MetricsAllowCIDRs(c.MetricsAllowCIDRs...))
+	}
+	if t := c.MetricsTLS; t != nil {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			fail(fmt.Errorf("fasthttpprometheus: loading metrics TLS certificate: %w", err))
+		} else {
+			add(MetricsTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+		}
+	}
+
+	return options
+}
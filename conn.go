@@ -0,0 +1,91 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// RequestReadDuration is an option which enables the request_read_duration_seconds histogram.
+//
+// For the first request on a connection, the time between accepting the
+// connection (ctx.ConnTime) and the start of request handling (ctx.Time)
+// covers the TLS handshake, if any, and reading and parsing the request
+// headers. It does not include the time the connection spent in the kernel
+// accept queue, nor reading a streamed request body. Later requests on a
+// keep-alive connection are not observed, as their measurement would include
+// the idle time between requests.
+func RequestReadDuration() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.readDuration = true
+	}
+}
+
+func (p *Prometheus) observeReadDuration(ctx *fasthttp.RequestCtx) {
+	if !p.readDuration || ctx.ConnRequestNum() != 1 {
+		return
+	}
+
+	p.readDur.WithLabelValues().Observe(p.durationValue(ctx.Time().Sub(ctx.ConnTime())))
+}
+
+// ConnectionRequests is an option which enables the connection_requests histogram of the number of each request
+// on its connection, ctx.ConnRequestNum, observed when responding. Its distribution tells whether clients reuse
+// their keep-alive connections or open a new connection for most requests
+func ConnectionRequests() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.connectionRequests = true
+	}
+}
+
+func (p *Prometheus) observeConnectionRequests(ctx *fasthttp.RequestCtx) {
+	if !p.connectionRequests {
+		return
+	}
+
+	p.connRequests.WithLabelValues().Observe(float64(ctx.ConnRequestNum()))
+}
+
+func (p *Prometheus) readDurationCollector() prometheus.Collector {
+	p.readDur = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("request_read_duration_seconds"),
+			Help:        "The time from accepting a connection to handling its first HTTP request in " + p.durationUnit.String() + ".",
+			Buckets:     p.durationBuckets([]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}),
+		},
+		nil,
+	)
+
+	return p.readDur
+}
+
+func (p *Prometheus) connectionRequestsCollector() prometheus.Collector {
+	p.connRequests = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("connection_requests"),
+			Help:        "The number of the HTTP requests on their connection when responding.",
+			Buckets:     []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+		},
+		nil,
+	)
+
+	return p.connRequests
+}
+
+// ConnectionLabel is an option which adds a connection label to the request counter and duration histogram, "new" for
+// the first request on a connection and "reused" for the requests on a keep-alive connection
+func ConnectionLabel() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.addExtraLabel("connection", func(ctx *fasthttp.RequestCtx) string {
+			if ctx.ConnRequestNum() == 1 {
+				return "new"
+			}
+			return "reused"
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package fasthttpprometheus
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// NewCounterVec creates a counter vec for application metrics with the namespace, subsystem and
+// const labels of the middleware and registers it on the same registry as the HTTP metrics.
+func (p *Prometheus) NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = p.customOpts(opts.Namespace, opts.Subsystem, opts.ConstLabels)
+	vec := prometheus.NewCounterVec(opts, labelNames)
+	p.registerCollectors(vec)
+	return vec
+}
+
+// NewGaugeVec creates a gauge vec for application metrics with the namespace, subsystem and
+// const labels of the middleware and registers it on the same registry as the HTTP metrics.
+func (p *Prometheus) NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = p.customOpts(opts.Namespace, opts.Subsystem, opts.ConstLabels)
+	vec := prometheus.NewGaugeVec(opts, labelNames)
+	p.registerCollectors(vec)
+	return vec
+}
+
+// NewHistogramVec creates a histogram vec for application metrics with the namespace, subsystem
+// and const labels of the middleware and registers it on the same registry as the HTTP metrics.
+// Without buckets the request duration buckets of the middleware are used.
+func (p *Prometheus) NewHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
+	opts.Namespace, opts.Subsystem, opts.ConstLabels = p.customOpts(opts.Namespace, opts.Subsystem, opts.ConstLabels)
+	if opts.Buckets == nil {
+		opts.Buckets = p.requestDurationBuckets()
+	}
+	vec := prometheus.NewHistogramVec(opts, labelNames)
+	p.registerCollectors(vec)
+	return vec
+}
+
+// customOpts fills in the namespace and subsystem of the middleware where they are not set
+// and merges its const labels into the given ones.
+func (p *Prometheus) customOpts(namespace, subsystem string, constLabels prometheus.Labels) (string, string, prometheus.Labels) {
+	if namespace == "" {
+		namespace = p.namespace
+	}
+	if subsystem == "" {
+		subsystem = p.subsystem
+	}
+
+	return namespace, subsystem, p.constLabelsWith(constLabels)
+}
@@ -0,0 +1,102 @@
+package fasthttpprometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+type deadline struct {
+	header string
+	reject bool
+
+	expired *prometheus.CounterVec
+	invalid *prometheus.CounterVec
+}
+
+// DeadlineHeader is an option which enables counting requests whose deadline, given in unix milliseconds by the named header, has already passed on arrival.
+// The requests are counted by the endpoint resolved before serving them, recorded as "other" with MaxEndpoints until the request counter records it
+func DeadlineHeader(name string, options ...func(*deadline)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		d := &deadline{header: name}
+		for _, option := range options {
+			option(d)
+		}
+		p.deadline = d
+	}
+}
+
+// RejectExpired is a DeadlineHeader option which answers expired requests with 504 without calling the handler
+func RejectExpired() func(*deadline) {
+	return func(d *deadline) {
+		d.reject = true
+	}
+}
+
+// checkDeadline inspects the deadline header of ctx and reports whether the request
+// may still be handled. endpoint is only called when the request is counted, and
+// must not admit new values into MaxEndpoints.
+func (p *Prometheus) checkDeadline(ctx *fasthttp.RequestCtx, endpoint func() string) bool {
+	d := p.deadline
+	if d == nil {
+		return true
+	}
+
+	value := ctx.Request.Header.Peek(d.header)
+	if len(value) == 0 {
+		return true
+	}
+
+	ms, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		e := endpoint()
+		d.invalid.WithLabelValues(e).Inc()
+		p.trackSeries("requests_deadline_invalid_total", e)
+		return true
+	}
+
+	if time.Now().UnixMilli() < ms {
+		return true
+	}
+
+	e := endpoint()
+	d.expired.WithLabelValues(e).Inc()
+	p.trackSeries("requests_expired_on_arrival_total", e)
+
+	if d.reject {
+		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusGatewayTimeout), fasthttp.StatusGatewayTimeout)
+		return false
+	}
+
+	return true
+}
+
+func (p *Prometheus) deadlineCollectors() []prometheus.Collector {
+	d := p.deadline
+
+	d.expired = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("requests_expired_on_arrival_total"),
+			Help:        "The HTTP requests whose deadline had already passed on arrival.",
+		},
+		[]string{"endpoint"},
+	)
+
+	d.invalid = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("requests_deadline_invalid_total"),
+			Help:        "The HTTP requests carrying an unparseable deadline header.",
+		},
+		[]string{"endpoint"},
+	)
+
+	return []prometheus.Collector{d.expired, d.invalid}
+}
@@ -0,0 +1,112 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// PathGroup rewrites the endpoint label values matching Pattern, replacing
+// the matches with Replacement as regexp.Regexp.ReplaceAllString does.
+type PathGroup struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// GroupPaths is an option which rewrites the endpoint label values with each of the groups in order
+func GroupPaths(groups []PathGroup) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.pathGroups = append(p.pathGroups, groups...)
+	}
+}
+
+// QueryParams is an option which appends the given query parameters, when present, to the endpoint label values,
+// e.g. /users?version=2. Other query parameters are never recorded
+func QueryParams(names ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.queryParams = append(p.queryParams, names...)
+	}
+}
+
+// MaxEndpoints is an option which bounds the number of distinct endpoint label values, recording the endpoints
+// beyond the first max distinct ones as "other". The endpoints deleted by DeleteEndpoint, Reset or SeriesTTL no longer
+// count towards max
+func MaxEndpoints(max int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if max <= 0 {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: MaxEndpoints %d is not positive", max))
+			return
+		}
+		p.endpointLimiter = newValueLimiter(max)
+	}
+}
+
+// normalizeEndpoint applies the configured rewrites to the endpoint label
+// value of the request in ctx before it is recorded. ctx is nil for the
+// endpoints of no request, which get no query parameters.
+func (p *Prometheus) normalizeEndpoint(ctx *fasthttp.RequestCtx, endpoint string) string {
+	endpoint = p.rewriteEndpoint(ctx, endpoint)
+
+	if p.endpointLimiter != nil {
+		endpoint = p.endpointLimiter.limit(endpoint)
+	}
+
+	return endpoint
+}
+
+// normalizeEndpointBefore is normalizeEndpoint for the endpoint label value
+// of the request in ctx before it is served, which may still be the request
+// path rather than the matched route. It is only recorded if MaxEndpoints
+// already admitted it, so the paths do not use up the endpoints of the
+// routes, and "other" otherwise.
+func (p *Prometheus) normalizeEndpointBefore(ctx *fasthttp.RequestCtx, endpoint string) string {
+	endpoint = p.rewriteEndpoint(ctx, endpoint)
+
+	if p.endpointLimiter != nil {
+		endpoint = p.endpointLimiter.check(endpoint)
+	}
+
+	return endpoint
+}
+
+// rewriteEndpoint applies PathGroups and QueryParams to endpoint.
+func (p *Prometheus) rewriteEndpoint(ctx *fasthttp.RequestCtx, endpoint string) string {
+	for _, g := range p.pathGroups {
+		endpoint = g.Pattern.ReplaceAllString(endpoint, g.Replacement)
+	}
+
+	if len(p.queryParams) > 0 && ctx != nil {
+		endpoint = p.appendQueryParams(ctx, endpoint)
+	}
+
+	return endpoint
+}
+
+// appendQueryParams appends the allowlisted query parameters of ctx to
+// endpoint, in the configured order.
+func (p *Prometheus) appendQueryParams(ctx *fasthttp.RequestCtx, endpoint string) string {
+	args := ctx.QueryArgs()
+
+	var b strings.Builder
+	sep := byte('?')
+	for _, name := range p.queryParams {
+		if !args.Has(name) {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(endpoint)
+		}
+		b.WriteByte(sep)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.Write(args.Peek(name))
+		sep = '&'
+	}
+
+	if b.Len() == 0 {
+		return endpoint
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EndpointBuckets is an option which sets the request duration buckets of specific endpoints, by endpoint label
+// value, e.g. {"/upload": {1, 10, 60, 300}}, while the other endpoints keep the buckets set with Buckets
+func EndpointBuckets(buckets map[string][]float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.endpointBuckets = buckets
+	}
+}
+
+// durationHistograms exposes the request duration histograms of the
+// endpoints with their own buckets along with the default one. They all
+// share the same descriptor, so they form a single metric family.
+type durationHistograms struct {
+	def       *prometheus.HistogramVec
+	endpoints map[string]*prometheus.HistogramVec
+}
+
+func (h durationHistograms) Describe(ch chan<- *prometheus.Desc) {
+	h.def.Describe(ch)
+}
+
+func (h durationHistograms) Collect(ch chan<- prometheus.Metric) {
+	h.def.Collect(ch)
+	for _, vec := range h.endpoints {
+		vec.Collect(ch)
+	}
+}
+
+// durationVec returns the request duration histogram of endpoint.
+func (p *Prometheus) durationVec(endpoint string) *prometheus.HistogramVec {
+	if vec, ok := p.reqDurEndpoints[endpoint]; ok {
+		return vec
+	}
+	return p.reqDur
+}
+
+// durationVecs returns all the request duration histograms.
+func (p *Prometheus) durationVecs() []*prometheus.HistogramVec {
+	vecs := []*prometheus.HistogramVec{p.reqDur}
+	for _, vec := range p.reqDurEndpoints {
+		vecs = append(vecs, vec)
+	}
+	return vecs
+}
+
+// durationCollector creates the request duration histograms from opts, using
+// the buckets set with EndpointBuckets for their endpoints.
+func (p *Prometheus) durationCollector(opts prometheus.HistogramOpts) prometheus.Collector {
+	p.reqDur = prometheus.NewHistogramVec(opts, p.labelNames())
+	if len(p.endpointBuckets) == 0 {
+		return p.reqDur
+	}
+
+	p.reqDurEndpoints = make(map[string]*prometheus.HistogramVec, len(p.endpointBuckets))
+	for endpoint, buckets := range p.endpointBuckets {
+		opts := opts
+		opts.Buckets = buckets
+		p.reqDurEndpoints[endpoint] = prometheus.NewHistogramVec(opts, p.labelNames())
+	}
+
+	return durationHistograms{def: p.reqDur, endpoints: p.reqDurEndpoints}
+}
+
+// validateEndpointBuckets checks the buckets set with EndpointBuckets.
+func (p *Prometheus) validateEndpointBuckets() error {
+	for _, buckets := range p.endpointBuckets {
+		if err := validateBuckets(buckets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
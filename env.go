@@ -0,0 +1,188 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// envPrefix is the prefix of the environment variables read by ConfigFromEnv.
+const envPrefix = "FASTHTTP_PROM_"
+
+// ConfigFromEnv returns the Config set by the environment. The Config in the
+// file named by FASTHTTP_PROM_CONFIG is loaded first, then overridden by
+//
+//	FASTHTTP_PROM_METRICS_PATH        comma separated metrics paths
+//	FASTHTTP_PROM_NAMESPACE           namespace of the metrics
+//	FASTHTTP_PROM_SUBSYSTEM           subsystem of the metrics
+//	FASTHTTP_PROM_CONST_LABELS        comma separated name=value pairs
+//	FASTHTTP_PROM_BUCKETS             comma separated duration buckets
+//	FASTHTTP_PROM_DURATION_UNIT       seconds or milliseconds
+//	FASTHTTP_PROM_STATUS_CLASS        true to record status classes
+//	FASTHTTP_PROM_ROUTE_TEMPLATES     true to record route templates
+//	FASTHTTP_PROM_MAX_ENDPOINTS       maximum number of endpoint label values
+//	FASTHTTP_PROM_SERIES_TTL          duration after which idle series expire
+//	FASTHTTP_PROM_METRICS_ALLOW_CIDRS comma separated networks allowed to scrape
+//	FASTHTTP_PROM_WORKER_LABEL        worker label value, pid for the process id
+//	FASTHTTP_PROM_PUSH_URL            Pushgateway url
+//	FASTHTTP_PROM_PUSH_JOB            Pushgateway job name
+//	FASTHTTP_PROM_PUSH_INTERVAL       Pushgateway push interval
+func ConfigFromEnv() (Config, error) {
+	var c Config
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		var err error
+		if c, err = LoadConfig(path); err != nil {
+			return c, err
+		}
+	}
+
+	e := envReader{}
+	e.strings("METRICS_PATH", &c.MetricsPaths)
+	e.string("NAMESPACE", &c.Namespace)
+	e.string("SUBSYSTEM", &c.Subsystem)
+	e.labels("CONST_LABELS", &c.ConstLabels)
+	e.floats("BUCKETS", &c.Buckets)
+	e.string("DURATION_UNIT", &c.DurationUnit)
+	e.bool("STATUS_CLASS", &c.StatusClass)
+	e.bool("ROUTE_TEMPLATES", &c.RouteTemplates)
+	e.int("MAX_ENDPOINTS", &c.MaxEndpoints)
+	e.duration("SERIES_TTL", &c.SeriesTTL)
+	e.strings("METRICS_ALLOW_CIDRS", &c.MetricsAllowCIDRs)
+	e.string("WORKER_LABEL", &c.WorkerLabel)
+
+	if url, ok := os.LookupEnv(envPrefix + "PUSH_URL"); ok {
+		c.PushGateway = &PushConfig{URL: url}
+	}
+	if c.PushGateway != nil {
+		e.string("PUSH_JOB", &c.PushGateway.Job)
+		e.duration("PUSH_INTERVAL", &c.PushGateway.Interval)
+	}
+
+	return c, e.err
+}
+
+// NewFromEnv creates the middleware with the options of ConfigFromEnv,
+// followed by options.
+func NewFromEnv(options ...func(*Prometheus)) (*Prometheus, error) {
+	c, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewFromConfig(c, options...)
+}
+
+// envReader parses the set environment variables into Config fields,
+// keeping the first error.
+type envReader struct {
+	err error
+}
+
+func (e *envReader) lookup(name string) (string, bool) {
+	if e.err != nil {
+		return "", false
+	}
+	return os.LookupEnv(envPrefix + name)
+}
+
+func (e *envReader) fail(name, value string, err error) {
+	e.err = fmt.Errorf("fasthttpprometheus: invalid %s%s %q: %w", envPrefix, name, value, err)
+}
+
+func (e *envReader) string(name string, dst *string) {
+	if v, ok := e.lookup(name); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) strings(name string, dst *[]string) {
+	if v, ok := e.lookup(name); ok {
+		*dst = splitList(v)
+	}
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(name, v, err)
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) int(name string, dst *int) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.fail(name, v, err)
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) floats(name string, dst *[]float64) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	var floats []float64
+	for _, s := range splitList(v) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			e.fail(name, v, err)
+			return
+		}
+		floats = append(floats, f)
+	}
+	*dst = floats
+}
+
+func (e *envReader) duration(name string, dst *model.Duration) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	d, err := model.ParseDuration(v)
+	if err != nil {
+		e.fail(name, v, err)
+		return
+	}
+	*dst = d
+}
+
+func (e *envReader) labels(name string, dst *map[string]string) {
+	v, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+	labels := map[string]string{}
+	for _, pair := range splitList(v) {
+		label, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			e.fail(name, v, fmt.Errorf("%q is not a name=value pair", pair))
+			return
+		}
+		labels[strings.TrimSpace(label)] = strings.TrimSpace(value)
+	}
+	*dst = labels
+}
+
+// splitList splits the comma separated list s, dropping empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
@@ -0,0 +1,63 @@
+package fasthttpprometheus
+
+import (
+	"encoding/hex"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// TraceExemplars is an option which attaches the trace ID of W3C traceparent headers as exemplar to the request counter and duration histogram, exposed with the OpenMetrics option
+func TraceExemplars() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.traceExemplars = true
+	}
+}
+
+// traceID returns the trace ID of the request in ctx recorded as exemplar,
+// or "" if it has none.
+func (p *Prometheus) traceID(ctx *fasthttp.RequestCtx) string {
+	if !p.traceExemplars {
+		return ""
+	}
+
+	return traceparentID(ctx.Request.Header.Peek("traceparent"))
+}
+
+// traceparentID returns the trace ID of a traceparent header value of the
+// form version-traceid-parentid-flags, or "" if it is not valid.
+func traceparentID(tp []byte) string {
+	// 2 hex digits version, 32 trace ID, 16 parent ID and 2 flags.
+	if len(tp) < 55 || tp[2] != '-' || tp[35] != '-' || tp[52] != '-' {
+		return ""
+	}
+	if len(tp) > 55 && (string(tp[:2]) == "00" || tp[55] != '-') {
+		return ""
+	}
+
+	traceID := tp[3:35]
+	var id [16]byte
+	if _, err := hex.Decode(id[:], traceID); err != nil || id == [16]byte{} {
+		return ""
+	}
+
+	return string(traceID)
+}
+
+// observe records v in o, with the exemplar if any.
+func observe(o prometheus.Observer, v float64, exemplar prometheus.Labels) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(v, exemplar)
+		return
+	}
+	o.Observe(v)
+}
+
+// inc increments c, with the exemplar if any.
+func inc(c prometheus.Counter, exemplar prometheus.Labels) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		ea.AddWithExemplar(1, exemplar)
+		return
+	}
+	c.Inc()
+}
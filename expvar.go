@@ -0,0 +1,84 @@
+package fasthttpprometheus
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// expvarObserver mirrors the request counter and durations into expvar.
+type expvarObserver struct {
+	requests *expvar.Map
+	total    expvar.Int
+
+	count    int64
+	duration int64 // nanoseconds
+}
+
+func (o *expvarObserver) ObserveRequest(stats RequestStats) {
+	o.requests.Add(strconv.Itoa(stats.Code), 1)
+	o.total.Add(1)
+	atomic.AddInt64(&o.count, 1)
+	atomic.AddInt64(&o.duration, int64(stats.Duration))
+}
+
+func (o *expvarObserver) durations() interface{} {
+	count := atomic.LoadInt64(&o.count)
+	sum := time.Duration(atomic.LoadInt64(&o.duration)).Seconds()
+
+	mean := 0.0
+	if count > 0 {
+		mean = sum / float64(count)
+	}
+
+	return map[string]interface{}{
+		"count": count,
+		"sum":   sum,
+		"mean":  mean,
+	}
+}
+
+// Expvar is an option which also publishes the request counts by code, the concurrent requests and the request durations
+// as an expvar map with the given name, served on /debug/vars by expvar.Handler
+func Expvar(name string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.expvarName = name
+	}
+}
+
+// publishExpvar publishes the expvar map configured with the Expvar option.
+func (p *Prometheus) publishExpvar() {
+	if p.expvarName == "" {
+		return
+	}
+
+	o := &expvarObserver{requests: new(expvar.Map).Init()}
+	p.observers = append(p.observers, o)
+
+	m := new(expvar.Map).Init()
+	m.Set("requests", o.requests)
+	m.Set("requests_total", &o.total)
+	m.Set("request_duration_seconds", expvar.Func(o.durations))
+	m.Set("concurrent_requests", expvar.Func(func() interface{} {
+		metric := &dto.Metric{}
+		if err := p.reqConcurrent.Write(metric); err != nil {
+			return nil
+		}
+		return metric.GetGauge().GetValue()
+	}))
+
+	expvar.Publish(p.expvarName, m)
+}
+
+// validateExpvar checks that the expvar name is not taken, as expvar.Publish
+// panics on duplicates.
+func validateExpvar(name string) error {
+	if name != "" && expvar.Get(name) != nil {
+		return fmt.Errorf("fasthttpprometheus: expvar %q is already published", name)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package fasthttpprometheus
+
+import (
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// WrapFastHTTPRouter is the counterpart of WrapHandler for a
+// github.com/fasthttp/router Router. With the RouteTemplates option, the
+// matched route is only known if r.SaveMatchedRoutePath was set before
+// registering the routes.
+func (p *Prometheus) WrapFastHTTPRouter(r *router.Router) fasthttp.RequestHandler {
+	return p.WrapHandler(r)
+}
+
+// fastRouterRouting gives access to the routes of a github.com/fasthttp/router Router.
+type fastRouterRouting struct {
+	r *router.Router
+}
+
+// pattern is not known without serving the request, as the router only
+// reports the matched route from within its handler.
+func (rt fastRouterRouting) pattern(method, path string) string {
+	return ""
+}
+
+func (rt fastRouterRouting) matched(ctx *fasthttp.RequestCtx) string {
+	route, _ := ctx.UserValue(router.MatchedRoutePathParam).(string)
+	return route
+}
+
+func (rt fastRouterRouting) redirect(ctx *fasthttp.RequestCtx) (kind, target string, ok bool) {
+	return routerRedirect(ctx, func(method, path string) (bool, bool) {
+		handler, tsr := rt.r.Lookup(method, path, nil)
+		return handler != nil, tsr
+	}, rt.r.RedirectTrailingSlash)
+}
+
+func (rt fastRouterRouting) unmatched(ctx *fasthttp.RequestCtx) bool {
+	handler, _ := rt.r.Lookup(string(ctx.Method()), string(ctx.Path()), nil)
+	return handler == nil
+}
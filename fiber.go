@@ -0,0 +1,68 @@
+package fasthttpprometheus
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// User value keys under which FiberHandler passes the fiber context and the
+// matched route through the instrumented handler.
+const (
+	fiberCtxKey   = "fasthttpprometheus.fiberCtx"
+	fiberRouteKey = "fasthttpprometheus.fiberRoute"
+)
+
+// FiberHandler returns a fiber middleware observing the requests served by
+// the rest of the handler chain, also serving the metrics on MetricsPath. With
+// the RouteTemplates option, the endpoint label is the path of the matched
+// fiber route. Errors returned by the chain are passed to the app's error
+// handler, so the observed status is the one sent to the client.
+func (p *Prometheus) FiberHandler() fiber.Handler {
+	metrics := p.metricsHandler()
+
+	h := p.instrument(func(ctx *fasthttp.RequestCtx) {
+		c := ctx.UserValue(fiberCtxKey).(*fiber.Ctx)
+
+		if !p.scoped && p.isMetricsPath(string(ctx.Path())) {
+			metrics(ctx)
+			return
+		}
+
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		ctx.SetUserValue(fiberRouteKey, c.Route().Path)
+	}, fiberRouting{})
+
+	return func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(fiberCtxKey, c)
+		h(c.Context())
+		return nil
+	}
+}
+
+// fiberRouting gives access to the route matched by fiber, as recorded by
+// FiberHandler.
+type fiberRouting struct{}
+
+func (fiberRouting) pattern(method, path string) string {
+	return ""
+}
+
+func (fiberRouting) matched(ctx *fasthttp.RequestCtx) string {
+	route, _ := ctx.UserValue(fiberRouteKey).(string)
+	return route
+}
+
+func (fiberRouting) redirect(ctx *fasthttp.RequestCtx) (kind, target string, ok bool) {
+	return "", "", false
+}
+
+// unmatched relies on the status, as the route recorded by FiberHandler is the
+// one of the middleware itself when no other route matched.
+func (fiberRouting) unmatched(ctx *fasthttp.RequestCtx) bool {
+	return unmatchedStatus(ctx.Response.StatusCode())
+}
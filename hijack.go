@@ -0,0 +1,36 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// observeHijacked counts the request in ctx in hijacked_requests_total and
+// reports whether its connection was hijacked, in which case its response
+// status and size are meaningless. The counter has the hijacked="true" label,
+// so its series can be selected together with the other request metrics by
+// their labels rather than their name.
+func (p *Prometheus) observeHijacked(ctx *fasthttp.RequestCtx, method, endpoint string) bool {
+	if !ctx.Hijacked() {
+		return false
+	}
+
+	p.hijacked.WithLabelValues(method, endpoint).Inc()
+	p.trackSeries("hijacked_requests_total", method, endpoint)
+	return true
+}
+
+func (p *Prometheus) hijackedCollector() prometheus.Collector {
+	p.hijacked = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabelsWith(prometheus.Labels{"hijacked": "true"}),
+			Name:        p.name("hijacked_requests_total"),
+			Help:        "The HTTP requests whose connection was hijacked by the handler.",
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	return p.hijacked
+}
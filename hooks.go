@@ -0,0 +1,39 @@
+package fasthttpprometheus
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// OnRequestStart is an option which adds a function called with each observed request before it is served
+func OnRequestStart(fn func(*fasthttp.RequestCtx)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.onStart = append(p.onStart, fn)
+	}
+}
+
+// OnRequestEnd is an option which adds a function called with each observed request and its stats once they are
+// recorded, which for responses streamed with SetBodyStream or SetBodyStreamWriter is after the body is sent.
+// ctx must not be retained after fn returns
+func OnRequestEnd(fn func(*fasthttp.RequestCtx, RequestStats)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.onEnd = append(p.onEnd, fn)
+	}
+}
+
+// requestStart calls the OnRequestStart functions with ctx.
+func (p *Prometheus) requestStart(ctx *fasthttp.RequestCtx) {
+	for _, fn := range p.onStart {
+		fn(ctx)
+	}
+}
+
+// requestEnd records stats, or queues it with AsyncObservations, and calls
+// the OnRequestEnd functions with ctx.
+func (p *Prometheus) requestEnd(ctx *fasthttp.RequestCtx, stats RequestStats) {
+	p.observeAsync(stats)
+	p.checkSlow(ctx, stats)
+
+	for _, fn := range p.onEnd {
+		fn(ctx, stats)
+	}
+}
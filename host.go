@@ -0,0 +1,34 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// HostLabel is an option which adds a host label to the request counter and duration histogram, the lowercased
+// request host without port. Hosts beyond the first maxHosts distinct ones are recorded as "other"
+func HostLabel(maxHosts int) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if maxHosts <= 0 {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: HostLabel maxHosts %d is not positive", maxHosts))
+			return
+		}
+		limiter := newValueLimiter(maxHosts)
+		p.addExtraLabel("host", func(ctx *fasthttp.RequestCtx) string {
+			return limiter.limit(normalizeHost(ctx.Host()))
+		})
+	}
+}
+
+// normalizeHost lowercases host and strips its port, along with the brackets
+// of IPv6 addresses.
+func normalizeHost(host []byte) string {
+	h := strings.ToLower(string(host))
+	if hostname, _, err := net.SplitHostPort(h); err == nil {
+		return hostname
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(h, "["), "]")
+}
@@ -0,0 +1,19 @@
+package fasthttpprometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHostLabelMaxHosts(t *testing.T) {
+	for _, max := range []int{0, -1} {
+		if _, err := NewPrometheusWithError(Registry(prometheus.NewRegistry()), HostLabel(max)); err == nil {
+			t.Errorf("HostLabel(%d): no error", max)
+		}
+	}
+
+	if _, err := NewPrometheusWithError(Registry(prometheus.NewRegistry()), HostLabel(1)); err != nil {
+		t.Errorf("HostLabel(1): %v", err)
+	}
+}
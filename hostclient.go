@@ -0,0 +1,72 @@
+package fasthttpprometheus
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// hostClientCollector collects the connection pool state of the host
+// clients added with CollectHostClient on scrape.
+type hostClientCollector struct {
+	register sync.Once
+
+	conns    *prometheus.Desc
+	maxConns *prometheus.Desc
+	pending  *prometheus.Desc
+
+	mu      sync.Mutex
+	clients []*fasthttp.HostClient
+}
+
+func (c *hostClientCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.conns
+	ch <- c.maxConns
+	ch <- c.pending
+}
+
+func (c *hostClientCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, hc := range c.clients {
+		maxConns := hc.MaxConns
+		if maxConns <= 0 {
+			maxConns = fasthttp.DefaultMaxConnsPerHost
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(hc.ConnsCount()), hc.Addr)
+		ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(maxConns), hc.Addr)
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(hc.PendingRequests()), hc.Addr)
+	}
+}
+
+// CollectHostClient adds hc to the host clients whose connection pool state, the established connections, the
+// connection limit and the pending requests, is collected on scrape by addr. The requests failing as no connection
+// is free are counted in client_request_errors_total with the no_free_conns error when sent with WrapClient. Each
+// host client must have a distinct Addr
+func (p *Prometheus) CollectHostClient(hc *fasthttp.HostClient) {
+	c := p.hostClients
+	c.register.Do(func() {
+		p.registerCollectors(c)
+	})
+
+	c.mu.Lock()
+	c.clients = append(c.clients, hc)
+	c.mu.Unlock()
+}
+
+// hostClientCollectors creates the collector of CollectHostClient, which is
+// not registered until it is called.
+func (p *Prometheus) hostClientCollectors() {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(p.namespace, p.subsystem, p.name(name)), help, []string{"addr"}, p.constLabels)
+	}
+
+	p.hostClients = &hostClientCollector{
+		conns:    desc("host_client_connections", "Number of established connections of the host client."),
+		maxConns: desc("host_client_max_connections", "The maximum number of connections of the host client."),
+		pending:  desc("host_client_pending_requests", "Number of requests being sent by the host client."),
+	}
+}
@@ -0,0 +1,47 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InFlightByEndpoint is an option which adds the requests_in_flight gauge of the requests being served by endpoint
+// and method. The endpoint is resolved before serving the request, so with routers reporting the matched route only
+// once served, such as github.com/fasthttp/router, it is the request path unless set with EndpointLabelFn. With
+// MaxEndpoints, the endpoints not yet recorded by the request counter are recorded as "other", so the request paths
+// neither take up the endpoints of the routes nor grow the gauge unbounded
+func InFlightByEndpoint() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.inFlightByEndpoint = true
+	}
+}
+
+// trackInFlight increments the requests_in_flight gauge of endpoint and
+// method and returns the function decrementing it, with the
+// InFlightByEndpoint option.
+func (p *Prometheus) trackInFlight(method string, endpoint func() string) func() {
+	if !p.inFlightByEndpoint {
+		return func() {}
+	}
+
+	e := endpoint()
+	g := p.inFlight.WithLabelValues(e, method)
+	p.trackSeries("requests_in_flight", e, method)
+
+	g.Inc()
+	return g.Dec
+}
+
+func (p *Prometheus) inFlightCollector() prometheus.Collector {
+	p.inFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("requests_in_flight"),
+			Help:        "Number of HTTP requests being served by endpoint.",
+		},
+		[]string{"endpoint", "method"},
+	)
+
+	return p.inFlight
+}
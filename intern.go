@@ -0,0 +1,87 @@
+package fasthttpprometheus
+
+import (
+	"strconv"
+	"sync"
+)
+
+// maxInterned bounds the number of strings an internCache keeps, so paths of
+// unbounded cardinality do not grow it forever; they are allocated instead.
+const maxInterned = 4096
+
+// internCache maps request bytes, like paths and content types, to the
+// strings recorded for them, so the requests do not allocate a string each.
+// Looking up a map with string(b) does not allocate.
+type internCache struct {
+	mu      sync.RWMutex
+	strings map[string]string
+}
+
+// interned holds the internCaches of a Prometheus and its scoped children.
+type interned struct {
+	paths      *internCache
+	mediaTypes *internCache
+}
+
+func newInternCache() *internCache {
+	return &internCache{strings: map[string]string{}}
+}
+
+// get returns the string convert returns for b, cached.
+func (c *internCache) get(b []byte, convert func([]byte) string) string {
+	c.mu.RLock()
+	s, ok := c.strings[string(b)]
+	c.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	s = convert(b)
+	c.mu.Lock()
+	if len(c.strings) < maxInterned {
+		c.strings[string(b)] = s
+	}
+	c.mu.Unlock()
+	return s
+}
+
+func bytesString(b []byte) string {
+	return string(b)
+}
+
+// methodString returns the method b as a string, without allocating for the
+// standard methods.
+func methodString(b []byte) string {
+	switch string(b) {
+	case "GET":
+		return "GET"
+	case "HEAD":
+		return "HEAD"
+	case "POST":
+		return "POST"
+	case "PUT":
+		return "PUT"
+	case "PATCH":
+		return "PATCH"
+	case "DELETE":
+		return "DELETE"
+	case "CONNECT":
+		return "CONNECT"
+	case "OPTIONS":
+		return "OPTIONS"
+	case "TRACE":
+		return "TRACE"
+	}
+	return string(b)
+}
+
+// statusCodes and statusClasses hold the code label values of the status
+// codes from 100 to 599, preformatted.
+var statusCodes, statusClasses [600]string
+
+func init() {
+	for code := 100; code < 600; code++ {
+		statusCodes[code] = strconv.Itoa(code)
+		statusClasses[code] = strconv.Itoa(code/100) + "xx"
+	}
+}
@@ -0,0 +1,91 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/common/model"
+	"github.com/valyala/fasthttp"
+)
+
+// extraLabel is a label added to the request counter and duration histogram,
+// whose value is extracted from each request.
+type extraLabel struct {
+	name    string
+	extract func(*fasthttp.RequestCtx) string
+}
+
+// ExtraLabels is an option which adds labels to the request counter and duration histogram, with values extracted
+// from each request by the given functions. The set of labels is fixed when initializing with New
+func ExtraLabels(labels map[string]func(*fasthttp.RequestCtx) string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		for name, extract := range labels {
+			p.addExtraLabel(name, extract)
+		}
+	}
+}
+
+// addExtraLabel adds a label extracted with extract, keeping the extra labels
+// sorted by name so their order does not depend on map iteration.
+func (p *Prometheus) addExtraLabel(name string, extract func(*fasthttp.RequestCtx) string) {
+	p.extraLabels = append(p.extraLabels, extraLabel{name: name, extract: extract})
+	sort.Slice(p.extraLabels, func(i, j int) bool {
+		return p.extraLabels[i].name < p.extraLabels[j].name
+	})
+}
+
+// extractLabels returns the values of the extra labels for the request in
+// ctx, or nil if there are none.
+func (p *Prometheus) extractLabels(ctx *fasthttp.RequestCtx) map[string]string {
+	if len(p.extraLabels) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(p.extraLabels))
+	for _, l := range p.extraLabels {
+		values[l.name] = l.extract(ctx)
+	}
+	return values
+}
+
+// labelNames returns the label names of the request counter and duration
+// histogram.
+func (p *Prometheus) labelNames() []string {
+	names := []string{"code", "method", "endpoint"}
+	if p.mountLabel {
+		names = append(names, "mount")
+	}
+	for _, l := range p.extraLabels {
+		names = append(names, l.name)
+	}
+	return names
+}
+
+// labelValues returns the label values matching labelNames. Missing extra
+// label values are empty.
+func (p *Prometheus) labelValues(code, method, endpoint string, extra map[string]string) []string {
+	lvs := []string{code, method, endpoint}
+	if p.mountLabel {
+		lvs = append(lvs, p.mount)
+	}
+	for _, l := range p.extraLabels {
+		lvs = append(lvs, extra[l.name])
+	}
+	return lvs
+}
+
+// validateExtraLabels checks that the extra label names are valid and do not
+// collide with the built-in labels or each other.
+func (p *Prometheus) validateExtraLabels() error {
+	seen := map[string]bool{"code": true, "method": true, "endpoint": true, "mount": p.mountLabel}
+	for _, l := range p.extraLabels {
+		if !model.LabelName(l.name).IsValid() {
+			return fmt.Errorf("fasthttpprometheus: invalid label name %q", l.name)
+		}
+		if seen[l.name] {
+			return fmt.Errorf("fasthttpprometheus: duplicate label name %q", l.name)
+		}
+		seen[l.name] = true
+	}
+	return nil
+}
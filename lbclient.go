@@ -0,0 +1,161 @@
+package fasthttpprometheus
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// lbMetrics are the collectors of the load balancing clients instrumented
+// with WrapLBClient, registered with the first of them.
+type lbMetrics struct {
+	register sync.Once
+
+	reqCnt *prometheus.CounterVec
+	reqDur *prometheus.HistogramVec
+	errs   *prometheus.CounterVec
+
+	mu        sync.Mutex
+	upstreams []*lbUpstream
+}
+
+// healthy returns the number of healthy upstreams.
+func (m *lbMetrics) healthy() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, u := range m.upstreams {
+		if atomic.LoadInt32(&u.healthy) == 1 {
+			n++
+		}
+	}
+	return float64(n)
+}
+
+// WrapLBClient instruments the upstream clients of lb, observing their requests in lb_upstream_requests_total and
+// lb_upstream_request_duration_seconds, by upstream address and code, and counting their errors in
+// lb_upstream_errors_total, along with the lb_healthy_upstreams gauge of the upstreams whose last request passed
+// lb.HealthCheck, or succeeded without one. It must be called before lb is first used
+func (p *Prometheus) WrapLBClient(lb *fasthttp.LBClient) {
+	m := p.lb
+	m.register.Do(func() {
+		healthy := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("lb_healthy_upstreams"),
+			Help:        "Number of healthy upstreams of the load balancing clients.",
+		}, m.healthy)
+
+		p.registerCollectors(m.reqCnt, m.reqDur, m.errs, healthy)
+	})
+
+	for i, c := range lb.Clients {
+		u := &lbUpstream{
+			BalancingClient: c,
+			addr:            upstreamAddr(c, i),
+			healthCheck:     lb.HealthCheck,
+			healthy:         1,
+			m:               m,
+			p:               p,
+		}
+		lb.Clients[i] = u
+
+		m.mu.Lock()
+		m.upstreams = append(m.upstreams, u)
+		m.mu.Unlock()
+	}
+}
+
+// upstreamAddr returns the upstream label value of the i-th client c of a
+// load balancing client.
+func upstreamAddr(c fasthttp.BalancingClient, i int) string {
+	switch c := c.(type) {
+	case *fasthttp.HostClient:
+		return c.Addr
+	case *fasthttp.PipelineClient:
+		return c.Addr
+	}
+	return strconv.Itoa(i)
+}
+
+// lbUpstream is an instrumented upstream client of a load balancing client.
+type lbUpstream struct {
+	fasthttp.BalancingClient
+
+	addr        string
+	healthCheck func(req *fasthttp.Request, resp *fasthttp.Response, err error) bool
+	healthy     int32
+
+	m *lbMetrics
+	p *Prometheus
+}
+
+func (u *lbUpstream) DoDeadline(req *fasthttp.Request, resp *fasthttp.Response, deadline time.Time) error {
+	start := time.Now()
+	err := u.BalancingClient.DoDeadline(req, resp, deadline)
+	elapsed := time.Since(start)
+
+	healthy := err == nil
+	if u.healthCheck != nil {
+		healthy = u.healthCheck(req, resp, err)
+	}
+	if healthy {
+		atomic.StoreInt32(&u.healthy, 1)
+	} else {
+		atomic.StoreInt32(&u.healthy, 0)
+	}
+
+	if err != nil {
+		u.m.errs.WithLabelValues(u.addr, clientErrorType(err)).Inc()
+		return err
+	}
+
+	code := u.p.statusLabel(resp.StatusCode())
+	u.m.reqCnt.WithLabelValues(u.addr, code).Inc()
+	u.m.reqDur.WithLabelValues(u.addr, code).Observe(u.p.durationValue(elapsed))
+	return nil
+}
+
+// lbCollectors creates the collectors of WrapLBClient, which are not
+// registered until it is called.
+func (p *Prometheus) lbCollectors() {
+	p.lb = &lbMetrics{
+		reqCnt: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("lb_upstream_requests_total"),
+				Help:        "The HTTP request counts sent to the upstreams of the load balancing clients.",
+			},
+			[]string{"upstream", "code"},
+		),
+		reqDur: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("lb_upstream_request_duration_seconds"),
+				Help:        "The HTTP request duration of the upstreams of the load balancing clients in " + p.durationUnit.String() + ".",
+				Buckets:     p.requestDurationBuckets(),
+			},
+			[]string{"upstream", "code"},
+		),
+		errs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("lb_upstream_errors_total"),
+				Help:        "The HTTP requests to the upstreams of the load balancing clients failing without response by error.",
+			},
+			[]string{"upstream", "error"},
+		),
+	}
+}
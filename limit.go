@@ -0,0 +1,65 @@
+package fasthttpprometheus
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// overflowLabel is recorded instead of label values beyond a valueLimiter's
+// limit.
+const overflowLabel = "other"
+
+// valueLimiter bounds the number of distinct values of a label.
+type valueLimiter struct {
+	max  int64
+	n    int64
+	seen sync.Map
+}
+
+func newValueLimiter(max int) *valueLimiter {
+	return &valueLimiter{max: int64(max)}
+}
+
+// limit returns value if it was already seen or the limit is not reached yet,
+// and overflowLabel otherwise.
+func (l *valueLimiter) limit(value string) string {
+	if _, ok := l.seen.Load(value); ok {
+		return value
+	}
+
+	if atomic.AddInt64(&l.n, 1) > l.max {
+		atomic.AddInt64(&l.n, -1)
+		return overflowLabel
+	}
+
+	if _, loaded := l.seen.LoadOrStore(value, struct{}{}); loaded {
+		atomic.AddInt64(&l.n, -1)
+	}
+
+	return value
+}
+
+// check returns value if it was already seen, and overflowLabel otherwise,
+// without counting value towards the limit.
+func (l *valueLimiter) check(value string) string {
+	if _, ok := l.seen.Load(value); ok {
+		return value
+	}
+	return overflowLabel
+}
+
+// forget forgets value, e.g. after its series were deleted, so another value
+// can take its place.
+func (l *valueLimiter) forget(value string) {
+	if _, loaded := l.seen.LoadAndDelete(value); loaded {
+		atomic.AddInt64(&l.n, -1)
+	}
+}
+
+// reset forgets all the values.
+func (l *valueLimiter) reset() {
+	l.seen.Range(func(value, _ interface{}) bool {
+		l.forget(value.(string))
+		return true
+	})
+}
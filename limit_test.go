@@ -0,0 +1,85 @@
+package fasthttpprometheus
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+)
+
+// The endpoint resolved before serving the request is the request path with
+// github.com/fasthttp/router, which must not take up the MaxEndpoints slots of
+// the route templates recorded once served.
+func TestMaxEndpointsInFlightByEndpoint(t *testing.T) {
+	p := NewPrometheus(
+		Registry(prometheus.NewRegistry()),
+		RouteTemplates(),
+		MaxEndpoints(3),
+		InFlightByEndpoint(),
+	)
+
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	noop := func(*fasthttp.RequestCtx) {}
+	r.GET("/users/{id}", noop)
+	r.GET("/items/{id}", noop)
+	h := p.WrapHandler(r)
+
+	for i := 0; i < 5; i++ {
+		serve(h, fasthttp.MethodGet, "/users/"+strconv.Itoa(i))
+	}
+	serve(h, fasthttp.MethodGet, "/items/1")
+
+	for _, endpoint := range []string{"/users/{id}", "/items/{id}"} {
+		if got := testutil.ToFloat64(p.reqCnt.WithLabelValues("200", fasthttp.MethodGet, endpoint)); got == 0 {
+			t.Errorf("requests_total of %s = 0, want recorded", endpoint)
+		}
+	}
+	if got := testutil.ToFloat64(p.reqCnt.WithLabelValues("200", fasthttp.MethodGet, overflowLabel)); got != 0 {
+		t.Errorf("requests_total of %s = %v, want 0", overflowLabel, got)
+	}
+
+	if n := testutil.CollectAndCount(p.inFlight); n != 1 {
+		t.Errorf("requests_in_flight has %d series, want 1 (%s)", n, overflowLabel)
+	}
+}
+
+// The requests with a malformed deadline are counted before being served, so
+// with github.com/fasthttp/router by request path, which must not take up the
+// MaxEndpoints slots of the route templates either.
+func TestMaxEndpointsDeadlineInvalid(t *testing.T) {
+	p := NewPrometheus(
+		Registry(prometheus.NewRegistry()),
+		RouteTemplates(),
+		MaxEndpoints(3),
+		DeadlineHeader("X-Deadline"),
+	)
+
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	noop := func(*fasthttp.RequestCtx) {}
+	r.GET("/users/{id}", noop)
+	r.GET("/items/{id}", noop)
+	h := p.WrapHandler(r)
+
+	for i := 0; i < 5; i++ {
+		var ctx fasthttp.RequestCtx
+		ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+		ctx.Request.SetRequestURI("/users/" + strconv.Itoa(i))
+		ctx.Request.Header.Set("X-Deadline", "invalid")
+		h(&ctx)
+	}
+	serve(h, fasthttp.MethodGet, "/items/1")
+
+	for _, endpoint := range []string{"/users/{id}", "/items/{id}"} {
+		if got := testutil.ToFloat64(p.reqCnt.WithLabelValues("200", fasthttp.MethodGet, endpoint)); got == 0 {
+			t.Errorf("requests_total of %s = 0, want recorded", endpoint)
+		}
+	}
+	if got := testutil.ToFloat64(p.deadline.invalid.WithLabelValues(overflowLabel)); got != 5 {
+		t.Errorf("requests_deadline_invalid_total of %s = %v, want 5", overflowLabel, got)
+	}
+}
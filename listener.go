@@ -0,0 +1,160 @@
+package fasthttpprometheus
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// listenerMetrics are the collectors of the listeners instrumented with
+// InstrumentListener, registered with the first of them.
+type listenerMetrics struct {
+	register sync.Once
+
+	accepted        prometheus.Counter
+	acceptErrors    prometheus.Counter
+	open            prometheus.Gauge
+	handshakeDur    prometheus.Histogram
+	handshakeErrors prometheus.Counter
+}
+
+// InstrumentListener returns a listener counting the connections accepted by ln, its accept errors and the open
+// connections, along with the duration and failures of the TLS handshakes of the connections of a tls.NewListener,
+// which cannot be seen from the request handler. The collectors are registered with the first instrumented listener
+func (p *Prometheus) InstrumentListener(ln net.Listener) net.Listener {
+	m := p.listener
+	m.register.Do(func() {
+		p.registerCollectors(m.accepted, m.acceptErrors, m.open, m.handshakeDur, m.handshakeErrors)
+	})
+
+	return &instrumentedListener{Listener: ln, m: m, p: p}
+}
+
+type instrumentedListener struct {
+	net.Listener
+	m *listenerMetrics
+	p *Prometheus
+}
+
+func (l *instrumentedListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		l.m.acceptErrors.Inc()
+		return nil, err
+	}
+
+	l.m.accepted.Inc()
+	l.m.open.Inc()
+
+	ic := &instrumentedConn{Conn: c, m: l.m}
+	if tc, ok := c.(*tls.Conn); ok {
+		return &instrumentedTLSConn{instrumentedConn: ic, tc: tc, p: l.p}, nil
+	}
+	return ic, nil
+}
+
+// instrumentedConn tracks the open connections.
+type instrumentedConn struct {
+	net.Conn
+	m      *listenerMetrics
+	closed sync.Once
+
+	mu       sync.Mutex
+	onClosed []func()
+}
+
+func (c *instrumentedConn) Close() error {
+	c.closed.Do(func() {
+		c.m.open.Dec()
+
+		c.mu.Lock()
+		onClosed := c.onClosed
+		c.mu.Unlock()
+		for _, fn := range onClosed {
+			fn()
+		}
+	})
+	return c.Conn.Close()
+}
+
+// onClose adds fn to the functions called once the connection is closed.
+func (c *instrumentedConn) onClose(fn func()) {
+	c.mu.Lock()
+	c.onClosed = append(c.onClosed, fn)
+	c.mu.Unlock()
+}
+
+// instrumentedTLSConn also measures the TLS handshake, which happens on the
+// first read or write. It implements Handshake and ConnectionState, so
+// fasthttp still recognizes the connection as TLS.
+type instrumentedTLSConn struct {
+	*instrumentedConn
+	tc *tls.Conn
+	p  *Prometheus
+
+	handshake    sync.Once
+	handshakeErr error
+}
+
+func (c *instrumentedTLSConn) Handshake() error {
+	c.handshake.Do(func() {
+		start := time.Now()
+		c.handshakeErr = c.tc.Handshake()
+		if c.handshakeErr != nil {
+			c.m.handshakeErrors.Inc()
+			return
+		}
+		c.m.handshakeDur.Observe(c.p.durationValue(time.Since(start)))
+	})
+	return c.handshakeErr
+}
+
+func (c *instrumentedTLSConn) ConnectionState() tls.ConnectionState {
+	return c.tc.ConnectionState()
+}
+
+func (c *instrumentedTLSConn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.tc.Read(b)
+}
+
+func (c *instrumentedTLSConn) Write(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.tc.Write(b)
+}
+
+// listenerCollectors creates the collectors of InstrumentListener, which are
+// not registered until it is called.
+func (p *Prometheus) listenerCollectors() {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name(name),
+			Help:        help,
+		}
+	}
+
+	p.listener = &listenerMetrics{
+		accepted:     prometheus.NewCounter(prometheus.CounterOpts(opts("connections_accepted_total", "The connections accepted by the listener."))),
+		acceptErrors: prometheus.NewCounter(prometheus.CounterOpts(opts("connection_accept_errors_total", "The errors of the listener accepting connections."))),
+		open:         prometheus.NewGauge(prometheus.GaugeOpts(opts("open_connections", "Number of open connections accepted by the listener."))),
+		handshakeDur: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("tls_handshake_duration_seconds"),
+			Help:        "The TLS handshake duration of the accepted connections in " + p.durationUnit.String() + ".",
+			Buckets:     p.durationBuckets([]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}),
+		}),
+		handshakeErrors: prometheus.NewCounter(prometheus.CounterOpts(opts("tls_handshake_errors_total", "The failed TLS handshakes of the accepted connections."))),
+	}
+}
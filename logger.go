@@ -0,0 +1,87 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// serverLogCategories maps substrings of the messages fasthttp.Server logs to
+// the category label of server_log_errors_total. The first entry whose
+// substrings are all contained in a message wins, so the errors wrapped by
+// "error when reading request headers" get the category of their cause.
+var serverLogCategories = []struct {
+	substrs  []string
+	category string
+}{
+	{[]string{"concurrent connections are served"}, "concurrency_limit"},
+	{[]string{"exceeds MaxConnsPerIP"}, "per_ip_limit"},
+	{[]string{"Timeout error when accepting new connections"}, "accept_timeout"},
+	{[]string{"Permanent error when accepting new connections"}, "accept_error"},
+	{[]string{"small read buffer"}, "small_read_buffer"},
+	{[]string{"body size exceeds the given limit"}, "body_too_large"},
+	{[]string{"read ", ": i/o timeout"}, "read_timeout"},
+	{[]string{"write ", ": i/o timeout"}, "write_timeout"},
+	{[]string{"broken pipe"}, "write_error"},
+	{[]string{"reset by peer"}, "connection_reset"},
+	{[]string{"unexpected EOF"}, "unexpected_eof"},
+	{[]string{"error when reading request headers"}, "header_parse"},
+}
+
+// serverLogCategory returns the category of a message logged by fasthttp.Server.
+func serverLogCategory(msg string) string {
+	for _, c := range serverLogCategories {
+		if containsAll(msg, c.substrs) {
+			return c.category
+		}
+	}
+	return "other"
+}
+
+func containsAll(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if !strings.Contains(s, substr) {
+			return false
+		}
+	}
+	return true
+}
+
+type serverLogger struct {
+	next fasthttp.Logger
+	errs *prometheus.CounterVec
+}
+
+func (l *serverLogger) Printf(format string, args ...interface{}) {
+	l.errs.WithLabelValues(serverLogCategory(fmt.Sprintf(format, args...))).Inc()
+
+	if l.next != nil {
+		l.next.Printf(format, args...)
+	}
+}
+
+// ServerLogger returns a fasthttp.Logger to be set as fasthttp.Server.Logger
+// which counts the logged errors in server_log_errors_total by category
+// before forwarding them to next. A nil next discards the messages. The
+// server logs the concurrency_limit and per_ip_limit errors at most once a
+// minute each, so they are counted as messages, not as rejected connections.
+func (p *Prometheus) ServerLogger(next fasthttp.Logger) fasthttp.Logger {
+	return &serverLogger{next: next, errs: p.serverLogErrs}
+}
+
+func (p *Prometheus) serverLogCollector() prometheus.Collector {
+	p.serverLogErrs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("server_log_errors_total"),
+			Help:        "The errors logged by the fasthttp server by category.",
+		},
+		[]string{"category"},
+	)
+
+	return p.serverLogErrs
+}
@@ -0,0 +1,72 @@
+package fasthttpprometheus
+
+import "testing"
+
+// The messages are logged by fasthttp v1.40.0 with LogAllErrors, captured
+// from a fasthttp.Server on 127.0.0.1:35649, or formatted as the server
+// formats them for the ones depending on the listener.
+func TestServerLogCategory(t *testing.T) {
+	tests := []struct {
+		msg      string
+		category string
+	}{
+		{
+			"The incoming connection cannot be served, because 2 concurrent connections are served. Try increasing Server.Concurrency",
+			"concurrency_limit",
+		},
+		{
+			"The number of connections from 127.0.0.1 exceeds MaxConnsPerIP=3",
+			"per_ip_limit",
+		},
+		{
+			"Timeout error when accepting new connections: accept tcp 127.0.0.1:35649: i/o timeout",
+			"accept_timeout",
+		},
+		{
+			"Permanent error when accepting new connections: accept tcp 127.0.0.1:35649: use of closed network connection",
+			"accept_error",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58266": error when reading request headers: small read buffer. Increase ReadBufferSize. Buffer size=1024, contents: "GET / HTTP/1.1\r\nHost: x\r\nX: aaaa"..."aaaa"`,
+			"small_read_buffer",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58270": body size exceeds the given limit`,
+			"body_too_large",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58252": error when reading request headers: read tcp 127.0.0.1:35649->127.0.0.1:58252: i/o timeout. Buffer size=25, contents: "GET / HTTP/1.1\r\nHost: x\r\n"`,
+			"read_timeout",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58326": write tcp 127.0.0.1:35649->127.0.0.1:58326: i/o timeout`,
+			"write_timeout",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58330": write tcp 127.0.0.1:35649->127.0.0.1:58330: write: broken pipe`,
+			"write_error",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58322": write tcp 127.0.0.1:35649->127.0.0.1:58322: write: connection reset by peer`,
+			"connection_reset",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58276": unexpected EOF`,
+			"unexpected_eof",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58306": error when reading request headers: cannot find http request method in "BLAH\r\n\r\n". Buffer size=8, contents: "BLAH\r\n\r\n"`,
+			"header_parse",
+		},
+		{
+			`error when serving connection "127.0.0.1:35649"<->"127.0.0.1:58290": empty hex number`,
+			"other",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := serverLogCategory(tt.msg); got != tt.category {
+			t.Errorf("serverLogCategory(%q) = %q, want %q", tt.msg, got, tt.category)
+		}
+	}
+}
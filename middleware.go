@@ -1,8 +1,12 @@
 package fasthttpprometheus
 
 import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/buaazp/fasthttprouter"
@@ -13,8 +17,7 @@ import (
 )
 
 var (
-	defaultMetricPath  = "/metrics"
-	requestHandlerPool sync.Pool
+	defaultMetricPath = "/metrics"
 )
 
 type FasthttpHandlerFunc func(*fasthttp.RequestCtx)
@@ -22,38 +25,220 @@ type FasthttpHandlerFunc func(*fasthttp.RequestCtx)
 type Prometheus struct {
 	reqCnt            *prometheus.CounterVec
 	reqDur            *prometheus.HistogramVec
-	reqSize, respSize prometheus.Summary
+	reqDurEndpoints   map[string]*prometheus.HistogramVec
+	endpointBuckets   map[string][]float64
+	reqSize, respSize *prometheus.SummaryVec
+	reqSizeHist       *prometheus.HistogramVec
+	reqSizeBuckets    []float64
+	summary           summaryConfig
+	nativeHistogram   *nativeHistogram
+	respSizeHist      *prometheus.HistogramVec
+	respSizeBuckets   []float64
+	sizeBuckets       []float64
 	router            *fasthttprouter.Router
 	reqConcurrent     prometheus.Gauge
 
-	registry  *prometheus.Registry
-	subsystem string
-
-	MetricsPath string
+	registerer   prometheus.Registerer
+	registry     prometheus.Gatherer
+	own          *prometheus.Registry // the middleware's own collectors
+	namespace    string
+	subsystem    string
+	constLabels  prometheus.Labels
+	naming       func(defaultName string) string
+	buckets      []float64
+	durationUnit Unit
+
+	series   map[string]*seriesTracker
+	deadline *deadline
+	preInit  *preInit
+	expiry   *seriesExpiry
+
+	mountLabel bool
+	mount      string
+	scoped     bool
+
+	readDuration bool
+	readDur      *prometheus.HistogramVec
+	queueHeader  string
+
+	connectionRequests bool
+	connRequests       *prometheus.HistogramVec
+	queueDur           *prometheus.HistogramVec
+
+	serverLogErrs   *prometheus.CounterVec
+	serverErrs      *prometheus.CounterVec
+	hijacked        *prometheus.CounterVec
+	routerRedirects *prometheus.CounterVec
+
+	recoverPanics bool
+	panics        *prometheus.CounterVec
+
+	unmatchedRequests bool
+	unmatched         *prometheus.CounterVec
+
+	inFlightByEndpoint bool
+	inFlight           *prometheus.GaugeVec
+
+	bytes *byteCounters
+
+	ttfb    bool
+	ttfbDur *prometheus.HistogramVec
+
+	listener *listenerMetrics
+	client   *clientMetrics
+	lb       *lbMetrics
+
+	hostClients *hostClientCollector
+	phases      *phaseMetrics
+	websockets  *websocketMetrics
+
+	streaming   map[string]bool
+	openStreams *prometheus.GaugeVec
+
+	bodyReadDuration bool
+	bodyReadDur      *prometheus.HistogramVec
+
+	extraLabels      []extraLabel
+	contentTypeLabel bool
+	statusClass      bool
+	skip             []func(*fasthttp.RequestCtx) bool
+	routeTemplates   bool
+	endpointFn       func(*fasthttp.RequestCtx) string
+	pathGroups       []PathGroup
+	queryParams      []string
+	endpointLimiter  *valueLimiter
+	traceExemplars   bool
+
+	// MetricsPath is the path the metrics are served on, along with the
+	// paths added with the MetricsPaths option.
+	MetricsPath       string
+	extraMetricsPaths []string
+	jsonPath          string
+	adminPath         string
+
+	handlerOpts promhttp.HandlerOpts
+	openMetrics bool
+	servers     *metricsServers
+	metricsTLS  *tls.Config
+	basicAuth   *basicAuth
+	allowlist   *allowlist
+
+	// optionErrs are the errors of the options given invalid arguments,
+	// reported by validate.
+	optionErrs []error
+
+	pushGateway *pushGateway
+	observers   []Observer
+
+	middlewares        []Middleware
+	onStart            []func(*fasthttp.RequestCtx)
+	onEnd              []func(*fasthttp.RequestCtx, RequestStats)
+	slow               *slowRequests
+	latencySLO         *latencySLO
+	apdex              *apdex
+	metricsInnermost   bool
+	expvarName         string
+	standardCollectors bool
+	buildInfo          *buildInfo
+	uptime             bool
+	multiprocess       *multiprocess
+	worker             string
+	children           *childCache
+	interned           *interned
+	sampleRate         float64
+	sampleThreshold    uint64
+	async              *asyncObservations
+
+	// disabled is set to 1 by Disable, shared with the scoped children.
+	disabled *int32
 }
 
 func NewPrometheus(options ...func(*Prometheus)) *Prometheus {
+	p, err := NewPrometheusWithError(options...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// NewPrometheusWithError is like NewPrometheus, but returns an error instead
+// of panicking when the options are invalid or the metrics cannot be
+// registered, e.g. because they are already registered on the registry.
+func NewPrometheusWithError(options ...func(*Prometheus)) (*Prometheus, error) {
 
 	p := &Prometheus{
 		MetricsPath: defaultMetricPath,
+		servers:     &metricsServers{},
+		disabled:    new(int32),
 	}
 
 	for _, option := range options {
 		option(p)
 	}
+	p.applyWorkerLabel()
 
-	p.registerMetrics()
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
 
-	return p
+	if err := p.registerMetrics(); err != nil {
+		return nil, err
+	}
+	p.publishExpvar()
+	p.startPush()
+	p.startExpiry()
+	p.startMultiprocess()
+	p.startAsync()
+
+	return p, nil
 }
 
 // Registry is an option allowing to set a  *prometheus.Registry with New
 func Registry(r *prometheus.Registry) func(*Prometheus) {
 	return func(p *Prometheus) {
+		if r == nil {
+			p.optionErrs = append(p.optionErrs, errors.New("fasthttpprometheus: Registry is nil"))
+			return
+		}
+		p.registerer = r
 		p.registry = r
 	}
 }
 
+// Registerer is an option allowing to register the metrics on r, e.g. a registerer wrapped with prometheus.WrapRegistererWith,
+// serving them from g on the metrics path, or from r if it is a prometheus.Gatherer itself and g is nil
+func Registerer(r prometheus.Registerer, g prometheus.Gatherer) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if r == nil {
+			p.optionErrs = append(p.optionErrs, errors.New("fasthttpprometheus: Registerer is nil"))
+			return
+		}
+		if g == nil {
+			g, _ = r.(prometheus.Gatherer)
+		}
+		if g == nil {
+			g = prometheus.DefaultGatherer
+		}
+		p.registerer = r
+		p.registry = g
+	}
+}
+
+// Namespace is an option which allows to set the namespace when initializing with New
+func Namespace(ns string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.namespace = ns
+	}
+}
+
+// gatherer returns the configured registry, or the default gatherer.
+func (p *Prometheus) gatherer() prometheus.Gatherer {
+	if p.registry == nil {
+		return prometheus.DefaultGatherer
+	}
+	return p.registry
+}
+
 // Subsystem is an option which allows to set the subsystem when initializing with New
 func Subsystem(sub string) func(*Prometheus) {
 	return func(p *Prometheus) {
@@ -61,52 +246,328 @@ func Subsystem(sub string) func(*Prometheus) {
 	}
 }
 
-func prometheusHandler(registry *prometheus.Registry) fasthttp.RequestHandler {
+// ConstLabels is an option which allows to set labels added to every metric when initializing with New
+func ConstLabels(labels prometheus.Labels) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.constLabels = labels
+	}
+}
+
+// constLabelsWith returns the configured const labels merged with extra.
+func (p *Prometheus) constLabelsWith(extra prometheus.Labels) prometheus.Labels {
+	labels := prometheus.Labels{}
+	for name, value := range p.constLabels {
+		labels[name] = value
+	}
+	for name, value := range extra {
+		labels[name] = value
+	}
+	return labels
+}
+
+// Naming is an option which allows to rename the metrics when initializing with New.
+// fn is given the default name of each metric, e.g. request_duration_seconds,
+// with the suffix of the DurationUnit, and returns the name to register it with, before namespace and subsystem are prefixed.
+func Naming(fn func(defaultName string) string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.naming = fn
+	}
+}
+
+// name returns the name of the metric with the given default name.
+func (p *Prometheus) name(defaultName string) string {
+	defaultName = p.unitName(defaultName)
+	if p.naming == nil {
+		return defaultName
+	}
+	return p.naming(defaultName)
+}
+
+// MetricsPaths is an option which allows to serve the metrics on several paths when initializing with New.
+// The first path is set as MetricsPath.
+func MetricsPaths(paths ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if len(paths) == 0 {
+			return
+		}
+		p.MetricsPath = paths[0]
+		p.extraMetricsPaths = paths[1:]
+	}
+}
+
+// metricsPaths returns all the paths the metrics are served on.
+func (p *Prometheus) metricsPaths() []string {
+	paths := append([]string{p.MetricsPath}, p.extraMetricsPaths...)
+	if p.jsonPath != "" {
+		paths = append(paths, p.jsonPath)
+	}
+	if p.adminPath != "" {
+		paths = append(paths, p.adminPath)
+	}
+	return paths
+}
+
+// isMetricsPath reports whether the metrics are served on path.
+func (p *Prometheus) isMetricsPath(path string) bool {
+	if path == p.MetricsPath || (p.jsonPath != "" && path == p.jsonPath) || (p.adminPath != "" && path == p.adminPath) {
+		return true
+	}
+	for _, extra := range p.extraMetricsPaths {
+		if path == extra {
+			return true
+		}
+	}
+	return false
+}
+
+// Buckets is an option which allows to set the buckets of the request duration histogram when initializing with New
+func Buckets(buckets []float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.buckets = buckets
+	}
+}
+
+// HandlerOpts is an option which allows to set the promhttp.HandlerOpts of the metrics handler when initializing with New,
+// e.g. to limit the scrape Timeout and MaxRequestsInFlight
+func HandlerOpts(opts promhttp.HandlerOpts) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.handlerOpts = opts
+	}
+}
+
+// OpenMetrics is an option which allows scrapers to negotiate the OpenMetrics format, exposing exemplars and _created timestamps
+func OpenMetrics() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.openMetrics = true
+	}
+}
+
+// metricsHandler returns the handler serving the metrics, protected as
+// configured by the options.
+func (p *Prometheus) metricsHandler() fasthttp.RequestHandler {
+	opts := p.handlerOpts
+	if p.openMetrics {
+		opts.EnableOpenMetrics = true
+	}
+
+	registry := p.registry
+	if p.multiprocess != nil {
+		registry = prometheus.GathererFunc(p.gatherMultiprocess)
+	}
+
+	h := prometheusHandler(registry, opts)
+	if p.jsonPath != "" {
+		text := h
+		h = func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) == p.jsonPath {
+				p.snapshotJSONHandler(ctx)
+				return
+			}
+			text(ctx)
+		}
+	}
+	if p.adminPath != "" {
+		metrics := h
+		h = func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) == p.adminPath {
+				p.adminHandler(ctx)
+				return
+			}
+			metrics(ctx)
+		}
+	}
+
+	if p.basicAuth != nil {
+		h = p.basicAuth.wrap(h)
+	}
+	if p.allowlist != nil {
+		h = p.allowlist.wrap(h)
+	}
+
+	return h
+}
+
+func prometheusHandler(registry prometheus.Gatherer, opts promhttp.HandlerOpts) fasthttp.RequestHandler {
 	if registry == nil {
-		return fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler())
+		return fasthttpadaptor.NewFastHTTPHandler(promhttp.InstrumentMetricHandler(
+			prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, opts),
+		))
 	}
 
-	return fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(registry, opts))
 }
 
-func (p *Prometheus) WrapHandler(r *fasthttprouter.Router) fasthttp.RequestHandler {
+// WrapHandler instruments the requests served by r, registering the metrics
+// handler on MetricsPath and the paths added with MetricsPaths.
+func (p *Prometheus) WrapHandler(r Router) fasthttp.RequestHandler {
 
 	// Setting prometheus metrics handler
-	r.GET(p.MetricsPath, prometheusHandler(p.registry))
+	if !p.scoped {
+		metrics := p.metricsHandler()
+		for _, path := range p.metricsPaths() {
+			r.GET(path, metrics)
+		}
+	}
+	p.preInitializeRoutes(r)
+
+	return p.instrument(r.Handler, routingOf(r))
+}
+
+// WrapHandlerFunc instruments any fasthttp.RequestHandler, without requiring a
+// router, and serves the metrics on MetricsPath itself.
+func (p *Prometheus) WrapHandlerFunc(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+	next := h
+	if !p.scoped {
+		metrics := p.metricsHandler()
+		next = func(ctx *fasthttp.RequestCtx) {
+			if p.isMetricsPath(string(ctx.Path())) {
+				metrics(ctx)
+				return
+			}
+			h(ctx)
+		}
+	}
+
+	return p.instrument(next, nil)
+}
+
+// instrument returns a handler observing the requests served by next, along
+// with the middlewares added with Use. rt gives access to the routes of the
+// router behind next, if any, to resolve route templates and router-issued
+// redirects.
+func (p *Prometheus) instrument(next fasthttp.RequestHandler, rt routing) fasthttp.RequestHandler {
+	if p.metricsInnermost {
+		return p.chain(p.observed(next, rt))
+	}
+	return p.observed(p.chain(next), rt)
+}
 
+// observed returns a handler observing the requests served by next.
+func (p *Prometheus) observed(next fasthttp.RequestHandler, rt routing) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
+		if p.skipped(ctx) {
+			next(ctx)
+			return
+		}
+
 		p.reqConcurrent.Inc()
 		defer p.reqConcurrent.Dec()
 
-		if string(ctx.Request.URI().Path()) == defaultMetricPath {
-			r.Handler(ctx)
+		if !p.scoped && p.isMetricsPath(string(ctx.Request.URI().Path())) {
+			next(ctx)
 			return
 		}
 
-		reqSize := make(chan int)
-		frc := acquireRequestFromPool()
-		ctx.Request.CopyTo(frc)
-		go computeApproximateRequestSize(frc, reqSize)
+		reqSize := computeApproximateRequestSize(&ctx.Request)
 
-		start := time.Now()
-		r.Handler(ctx)
+		method := methodString(ctx.Method())
+		path := p.interned.paths.get(ctx.Request.URI().Path(), bytesString)
 
-		status := strconv.Itoa(ctx.Response.StatusCode())
-		elapsed := float64(time.Since(start)) / float64(time.Second)
-		respSize := float64(len(ctx.Response.Body()))
+		p.observeReadDuration(ctx)
+		p.observeQueueDuration(ctx)
 
-		method := string(ctx.Method())
-		endpoint := string(ctx.Request.URI().Path())
+		// endpointBefore returns the endpoint label value of the request
+		// before it is served.
+		endpointBefore := func() string {
+			if p.endpointFn != nil {
+				return p.normalizeEndpointBefore(ctx, p.endpointFn(ctx))
+			}
+			return p.normalizeEndpointBefore(ctx, p.endpointOf(rt, method, path))
+		}
 
-		p.reqDur.WithLabelValues(status, method, endpoint).Observe(elapsed)
-		p.reqCnt.WithLabelValues(status, method, endpoint).Inc()
-		p.reqSize.Observe(float64(<-reqSize))
-		p.respSize.Observe(respSize)
+		defer p.trackInFlight(method, endpointBefore)()
+		p.requestStart(ctx)
+
+		start := time.Now()
+
+		// The request is observed in a deferred call, so that it is still
+		// recorded, with code 500, when next panics and the panic is
+		// recovered further up.
+		panicked := true
+		defer func() {
+			elapsed := time.Since(start)
+			p.observeConnectionRequests(ctx)
+
+			code := ctx.Response.StatusCode()
+			if panicked {
+				code = fasthttp.StatusInternalServerError
+			}
+
+			endpoint := p.endpoint(rt, ctx, path)
+			redirected := false
+			if rt != nil && !panicked {
+				if kind, target, ok := rt.redirect(ctx); ok {
+					redirected = true
+					p.routerRedirects.WithLabelValues(kind).Inc()
+					if p.endpointFn == nil {
+						endpoint = p.endpointOf(rt, method, target)
+					}
+				}
+			}
+
+			if !panicked && p.observeUpgrade(ctx) {
+				return
+			}
+
+			if !panicked && !redirected && p.observeUnmatched(ctx, rt, method, code) {
+				return
+			}
+
+			endpoint = p.normalizeEndpoint(ctx, endpoint)
+
+			if !panicked && p.observeHijacked(ctx, method, endpoint) {
+				return
+			}
+
+			stats := RequestStats{
+				Code:             code,
+				Method:           method,
+				Endpoint:         endpoint,
+				Duration:         elapsed,
+				RequestSize:      reqSize,
+				ResponseSize:     responseSize(ctx),
+				TimeToFirstByte:  elapsed,
+				BodyReadDuration: bodyReadDuration(ctx),
+				TraceID:          p.traceID(ctx),
+				Labels:           p.extractLabels(ctx),
+				ContentType:      p.interned.mediaTypes.get(ctx.Response.Header.ContentType(), mediaType),
+			}
+
+			if s := bodyStream(ctx); s != nil && !panicked {
+				// Only streamed requests move their stats to the heap.
+				stats := stats
+				closeStream := p.openStream(endpoint)
+				s.onDone(func(n int) {
+					closeStream()
+					stats.ResponseSize = n
+					if !s.firstRead.IsZero() {
+						stats.TimeToFirstByte = s.firstRead.Sub(start)
+					}
+					if p.streaming[endpoint] {
+						stats.Duration = stats.TimeToFirstByte
+					}
+					p.requestEnd(ctx, stats)
+				})
+				return
+			}
+
+			p.requestEnd(ctx, stats)
+		}()
+
+		if p.checkDeadline(ctx, endpointBefore) {
+			p.serve(ctx, next, method, func() string {
+				return p.normalizeEndpoint(ctx, p.endpoint(rt, ctx, path))
+			})
+		}
+		panicked = false
 	}
 }
 
 // Idea is from https://github.com/DanielHeckrath/gin-prometheus/blob/master/gin_prometheus.go and https://github.com/zsais/go-gin-prometheus/blob/master/middleware.go
-func computeApproximateRequestSize(ctx *fasthttp.Request, out chan int) {
+// computeApproximateRequestSize returns the approximate size of the request,
+// read from the live request before it is served, so the handler cannot
+// change it, and without copying it.
+func computeApproximateRequestSize(ctx *fasthttp.Request) int {
 	s := 0
 	if ctx.URI() != nil {
 		s += len(ctx.URI().Path())
@@ -126,77 +587,348 @@ func computeApproximateRequestSize(ctx *fasthttp.Request, out chan int) {
 		s += ctx.Header.ContentLength()
 	}
 
-	out <- s
+	return s
 }
 
-func (p *Prometheus) registerMetrics() {
+// ContentTypeLabel is an option which adds a content_type label, the media type without parameters, to the response size summary
+func ContentTypeLabel() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.contentTypeLabel = true
+	}
+}
+
+// mediaType returns the lowercased media type of a Content-Type header value,
+// without parameters.
+func mediaType(contentType []byte) string {
+	if i := bytes.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(string(bytes.TrimSpace(contentType)))
+}
+
+// Skip is an option which excludes the requests for which skip returns true from the metrics, while still serving them
+func Skip(skip func(*fasthttp.RequestCtx) bool) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.skip = append(p.skip, skip)
+	}
+}
+
+// skipped reports whether the request in ctx is excluded by the Skip options,
+// or all requests are while the middleware is disabled.
+func (p *Prometheus) skipped(ctx *fasthttp.RequestCtx) bool {
+	if !p.Enabled() {
+		return true
+	}
+	for _, skip := range p.skip {
+		if skip(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusClass is an option which records the code label as status class, e.g. 2xx or 5xx, instead of the status code
+func StatusClass() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.statusClass = true
+	}
+}
+
+// statusLabel returns the code label value of the status code.
+func (p *Prometheus) statusLabel(code int) string {
+	if code < 100 || code >= 600 {
+		return strconv.Itoa(code)
+	}
+	if p.statusClass {
+		return statusClasses[code]
+	}
+	return statusCodes[code]
+}
+
+// ObserveRequest records stats in the Prometheus collectors, making p the
+// default Observer.
+func (p *Prometheus) ObserveRequest(stats RequestStats) {
+	status := p.statusLabel(stats.Code)
+	elapsed := p.durationValue(stats.Duration)
+
+	var exemplar prometheus.Labels
+	if stats.TraceID != "" {
+		exemplar = prometheus.Labels{"trace_id": stats.TraceID}
+	}
+
+	lvs := func() []string {
+		return p.labelValues(status, stats.Method, stats.Endpoint, stats.Labels)
+	}
+	children := p.requestChildren(status, stats.Method, stats.Endpoint, lvs)
+	sampled := p.sampled()
+	if sampled {
+		observe(children.duration, elapsed, exemplar)
+	}
+	inc(children.count, exemplar)
+	children.touch()
+	if sampled {
+		p.observeRequestSize(stats)
+		p.observeResponseSize(stats, status)
+		p.observeTimeToFirstByte(stats)
+		p.observeBodyReadDuration(stats)
+	}
+	p.countBytes(stats)
+	p.observeSLO(stats)
+	p.observeApdex(stats)
+}
 
-	RequestDurationBucket := []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 15, 20, 30, 40, 50, 60}
+// requestDurationBuckets returns the buckets of the request duration
+// histogram.
+func (p *Prometheus) requestDurationBuckets() []float64 {
+	if p.buckets != nil {
+		return p.buckets
+	}
+	return p.durationBuckets([]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 15, 20, 30, 40, 50, 60})
+}
+
+func (p *Prometheus) registerMetrics() error {
+	p.children = newChildCache()
+	p.interned = &interned{paths: newInternCache(), mediaTypes: newInternCache()}
 
 	p.reqCnt = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Subsystem: p.subsystem,
-			Name:      "requests_total",
-			Help:      "The HTTP request counts processed.",
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("requests_total"),
+			Help:        "The HTTP request counts processed.",
 		},
-		[]string{"code", "method", "endpoint"},
+		p.labelNames(),
 	)
 
-	p.reqDur = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Subsystem: p.subsystem,
-			Name:      "request_duration_seconds",
-			Help:      "The HTTP request duration in seconds.",
-			Buckets:   RequestDurationBucket,
-		},
-		[]string{"code", "method", "endpoint"},
-	)
+	reqDurOpts := prometheus.HistogramOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("request_duration_seconds"),
+		Help:        "The HTTP request duration in " + p.durationUnit.String() + ".",
+		Buckets:     p.requestDurationBuckets(),
+	}
+	p.nativeHistogram.apply(&reqDurOpts)
+	reqDur := p.durationCollector(reqDurOpts)
 
-	p.reqSize = prometheus.NewSummary(
-		prometheus.SummaryOpts{
-			Subsystem: p.subsystem,
-			Name:      "request_size_bytes",
-			Help:      "The HTTP request sizes in bytes.",
-		},
-	)
+	var reqSize prometheus.Collector
+	if p.reqSizeBuckets != nil {
+		reqSize = p.requestSizeCollector()
+	} else {
+		p.reqSize = prometheus.NewSummaryVec(
+			p.summaryOpts("request_size_bytes", "The HTTP request sizes in bytes."),
+			nil,
+		)
+		reqSize = p.reqSize
+	}
 
-	p.respSize = prometheus.NewSummary(
-		prometheus.SummaryOpts{
-			Subsystem: p.subsystem,
-			Name:      "response_size_bytes",
-			Help:      "The HTTP response sizes in bytes.",
-		},
-	)
+	var respSize prometheus.Collector
+	if p.respSizeBuckets != nil {
+		respSize = p.responseSizeCollector()
+	} else {
+		respSizeOpts := p.summaryOpts("response_size_bytes", "The HTTP response sizes in bytes.")
+
+		var respSizeLabels []string
+		if p.contentTypeLabel {
+			respSizeLabels = []string{"content_type"}
+		}
+		p.respSize = prometheus.NewSummaryVec(respSizeOpts, respSizeLabels)
+		respSize = p.respSize
+	}
 
 	p.reqConcurrent = prometheus.NewGauge(prometheus.GaugeOpts{
-		Subsystem: p.subsystem,
-		Name:      "concurrent_requests",
-		Help:      "Number of concurrent HTTP requests",
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("concurrent_requests"),
+		Help:        "Number of concurrent HTTP requests",
 	},
 	)
 
 	collectors := []prometheus.Collector{
 		p.reqConcurrent,
 		p.reqCnt,
-		p.reqDur,
-		p.reqSize,
-		p.respSize,
+		reqDur,
+		reqSize,
+		respSize,
+		p.serverLogCollector(),
+		p.serverErrorCollector(),
+		p.hijackedCollector(),
+		p.routerRedirectCollector(),
+	}
+	labeled := []string{"requests_total", "request_duration_seconds", "hijacked_requests_total"}
+	if p.reqSizeHist != nil {
+		labeled = append(labeled, "request_size_bytes")
+	}
+	if p.respSizeHist != nil || p.contentTypeLabel {
+		labeled = append(labeled, "response_size_bytes")
 	}
 
-	if p.registry != nil {
-		p.registry.MustRegister(collectors...)
-	} else {
-		prometheus.MustRegister(collectors...)
+	if p.deadline != nil {
+		collectors = append(collectors, p.deadlineCollectors()...)
+		labeled = append(labeled, "requests_expired_on_arrival_total", "requests_deadline_invalid_total")
+	}
+
+	if p.readDuration {
+		collectors = append(collectors, p.readDurationCollector())
+	}
+
+	if p.connectionRequests {
+		collectors = append(collectors, p.connectionRequestsCollector())
 	}
+
+	if p.queueHeader != "" {
+		collectors = append(collectors, p.queueDurationCollector())
+	}
+
+	if p.bodyReadDuration {
+		collectors = append(collectors, p.bodyReadDurationCollector())
+		labeled = append(labeled, "request_body_read_duration_seconds")
+	}
+
+	if p.ttfb {
+		collectors = append(collectors, p.ttfbCollector())
+		labeled = append(labeled, "response_ttfb_seconds")
+	}
+
+	if p.bytes != nil {
+		collectors = append(collectors, p.byteCollectors()...)
+		if p.bytes.byEndpoint {
+			labeled = append(labeled, "request_bytes_total", "response_bytes_total")
+		}
+	}
+
+	if p.inFlightByEndpoint {
+		collectors = append(collectors, p.inFlightCollector())
+		labeled = append(labeled, "requests_in_flight")
+	}
+
+	if p.apdex != nil {
+		collectors = append(collectors, p.apdexCollector())
+		labeled = append(labeled, "apdex_requests_total")
+	}
+
+	if p.latencySLO != nil {
+		collectors = append(collectors, p.sloCollector())
+		labeled = append(labeled, "requests_within_slo_total")
+	}
+
+	if p.slow != nil {
+		collectors = append(collectors, p.slowCollector())
+		labeled = append(labeled, "slow_requests_total")
+	}
+
+	if p.streaming != nil {
+		collectors = append(collectors, p.openStreamsCollector())
+		labeled = append(labeled, "open_streams")
+	}
+
+	if p.websockets != nil {
+		collectors = append(collectors, p.websocketCollectors()...)
+	}
+
+	if p.unmatchedRequests {
+		collectors = append(collectors, p.unmatchedCollector())
+	}
+
+	if p.recoverPanics {
+		collectors = append(collectors, p.panicCollector())
+		labeled = append(labeled, "panics_total")
+	}
+
+	if p.allowlist != nil {
+		collectors = append(collectors, p.allowlistCollector())
+	}
+
+	if p.pushGateway != nil {
+		collectors = append(collectors, p.pushCollector())
+	}
+
+	if p.buildInfo != nil {
+		collectors = append(collectors, p.buildInfoCollector())
+	}
+
+	if p.uptime {
+		collectors = append(collectors, p.uptimeCollectors()...)
+	}
+
+	if p.multiprocess != nil {
+		collectors = append(collectors, p.multiprocessCollectors()...)
+	}
+
+	if p.async != nil {
+		collectors = append(collectors, p.asyncCollector())
+	}
+
+	collectors = append(collectors, p.seriesCollectors(labeled...)...)
+
+	unregisterStandard, err := p.registerStandardCollectors()
+	if err != nil {
+		return err
+	}
+
+	p.own = prometheus.NewRegistry()
+	if err := p.register(collectors...); err != nil {
+		unregisterStandard()
+		return err
+	}
+	p.listenerCollectors()
+	p.clientCollectors()
+	p.lbCollectors()
+	p.hostClientCollectors()
+	p.phaseCollectors()
+
+	p.initUnlabeled()
+	p.preInitialize()
+	return nil
 }
 
-func acquireRequestFromPool() *fasthttp.Request {
-	rp := requestHandlerPool.Get()
+// registerCollectors registers collectors with the registry, or the default
+// one, along with the middleware's own registry. It panics if one of them
+// cannot be registered.
+func (p *Prometheus) registerCollectors(collectors ...prometheus.Collector) {
+	if err := p.register(collectors...); err != nil {
+		panic(err)
+	}
+}
 
-	if rp == nil {
-		return new(fasthttp.Request)
+// register registers collectors like registerCollectors, but returns an
+// error instead, after unregistering the collectors registered before.
+func (p *Prometheus) register(collectors ...prometheus.Collector) error {
+	r := p.registerer
+	if r == nil {
+		r = prometheus.DefaultRegisterer
 	}
 
-	frc := rp.(*fasthttp.Request)
-	return frc
+	for i, c := range collectors {
+		if err := r.Register(c); err != nil {
+			for _, registered := range collectors[:i] {
+				r.Unregister(registered)
+			}
+			return fmt.Errorf("fasthttpprometheus: registering %s: %w", describe(c), err)
+		}
+	}
+
+	for _, c := range collectors {
+		p.own.MustRegister(c)
+	}
+	return nil
+}
+
+// describe returns the description of the first metric of c for errors.
+func describe(c prometheus.Collector) string {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+
+	s := "metrics"
+	for d := range descs {
+		if s == "metrics" {
+			s = d.String()
+		}
+	}
+	return s
 }
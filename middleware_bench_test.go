@@ -0,0 +1,93 @@
+package fasthttpprometheus
+
+import (
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// benchmarkConfigs are the configurations the request path is benchmarked
+// with: the defaults, and route templates with extra labels, which bypass
+// the cache of the request counter and duration children.
+var benchmarkConfigs = []struct {
+	name    string
+	options []func(*Prometheus)
+}{
+	{"defaults", nil},
+	{"RouteTemplates+ExtraLabels", []func(*Prometheus){
+		RouteTemplates(),
+		ExtraLabels(map[string]func(*fasthttp.RequestCtx) string{
+			"channel": func(ctx *fasthttp.RequestCtx) string { return "web" },
+		}),
+	}},
+}
+
+// benchmarkServe serves the request for uri with h b.N times, reusing the
+// context between the requests like fasthttp.Server does.
+func benchmarkServe(b *testing.B, h fasthttp.RequestHandler, uri string) {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI(uri)
+	ctx.Request.Header.Set(fasthttp.HeaderUserAgent, "benchmark")
+	ctx.Request.Header.Set(fasthttp.HeaderAccept, "application/json")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Response.Reset()
+		h(&ctx)
+	}
+}
+
+func BenchmarkWrapHandler(b *testing.B) {
+	for _, c := range benchmarkConfigs {
+		b.Run(c.name, func(b *testing.B) {
+			p := NewPrometheus(append([]func(*Prometheus){Registry(prometheus.NewRegistry())}, c.options...)...)
+			r := router.New()
+			r.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+				ctx.SetBodyString("ok")
+			})
+
+			benchmarkServe(b, p.WrapHandler(r), "/users/42")
+		})
+	}
+}
+
+func BenchmarkWrapHandlerFunc(b *testing.B) {
+	for _, c := range benchmarkConfigs {
+		b.Run(c.name, func(b *testing.B) {
+			p := NewPrometheus(append([]func(*Prometheus){Registry(prometheus.NewRegistry())}, c.options...)...)
+			h := p.WrapHandlerFunc(func(ctx *fasthttp.RequestCtx) {
+				ctx.SetBodyString("ok")
+			})
+
+			benchmarkServe(b, h, "/users/42")
+		})
+	}
+}
+
+// BenchmarkRequestSize serves a request with a body and headers, whose
+// approximate size is computed for request_size_bytes.
+func BenchmarkRequestSize(b *testing.B) {
+	p := NewPrometheus(Registry(prometheus.NewRegistry()))
+	h := p.WrapHandlerFunc(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	})
+
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("http://example.com/upload")
+	ctx.Request.Header.SetContentType("application/octet-stream")
+	ctx.Request.Header.Set(fasthttp.HeaderUserAgent, "benchmark")
+	ctx.Request.Header.Set(fasthttp.HeaderAuthorization, "Bearer token")
+	ctx.Request.SetBody(make([]byte, 1024))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Response.Reset()
+		h(&ctx)
+	}
+}
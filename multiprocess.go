@@ -0,0 +1,466 @@
+package fasthttpprometheus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// multiprocessExt is the extension of the files the processes write their
+// metrics to.
+const multiprocessExt = ".pb"
+
+// multiprocessStale is the number of intervals after which the file of a
+// process not written since is considered to be the file of an exited one.
+const multiprocessStale = 3
+
+// GaugeAggregation is how Multiprocess aggregates the values of a gauge
+// across the processes.
+type GaugeAggregation int
+
+const (
+	// LiveSum sums the values of the processes still running, the default
+	// for gauges, so e.g. the requests in flight of exited processes are
+	// not counted forever.
+	LiveSum GaugeAggregation = iota
+	// Sum sums the values of all the processes, like for counters.
+	Sum
+	// Max takes the greatest value of the processes still running.
+	Max
+	// Min takes the least value of the processes still running.
+	Min
+)
+
+type multiprocess struct {
+	dir      string
+	interval time.Duration
+	file     string
+	errs     prometheus.Counter
+	readErrs prometheus.Counter
+	// gauges are the aggregations set with MultiprocessGauge, by metric name.
+	gauges map[string]GaugeAggregation
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Multiprocess is an option for prefork deployments, where several processes serve the same port: every interval, each
+// process writes the metrics of the middleware to a file in dir, and the metrics handler of every process serves them
+// summed across the files, so scraping any of the processes returns the metrics of all of them. Gauges are summed over
+// the processes which wrote their file within the last 3 intervals, except build_info and uptime_seconds, which take the
+// greatest value, and start_time_seconds the least, see MultiprocessGauge. Summaries only keep their count and sum, and
+// histograms their classic buckets. dir must be emptied before the processes are started, as the files of exited
+// processes are kept so their counters do not go backwards. Files which cannot be read are skipped and counted in
+// multiprocess_read_errors_total
+func Multiprocess(dir string, interval time.Duration) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if dir == "" {
+			p.optionErrs = append(p.optionErrs, errors.New("fasthttpprometheus: Multiprocess directory is empty"))
+			return
+		}
+		if interval <= 0 {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: Multiprocess interval %v is not positive", interval))
+			return
+		}
+		if p.multiprocess == nil {
+			p.multiprocess = &multiprocess{gauges: map[string]GaugeAggregation{}}
+		}
+		p.multiprocess.dir = dir
+		p.multiprocess.interval = interval
+	}
+}
+
+// MultiprocessGauge is an option which sets how Multiprocess aggregates the gauge named name, as exposed, e.g. to take the
+// greatest value of a custom gauge reporting the same value in every process
+func MultiprocessGauge(name string, aggregation GaugeAggregation) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if aggregation < LiveSum || aggregation > Min {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: MultiprocessGauge aggregation %d is invalid", aggregation))
+			return
+		}
+		if p.multiprocess == nil {
+			p.multiprocess = &multiprocess{gauges: map[string]GaugeAggregation{}}
+		}
+		p.multiprocess.gauges[name] = aggregation
+	}
+}
+
+// validate creates the directory of the files if it does not exist.
+func (m *multiprocess) validate() error {
+	if m == nil {
+		return nil
+	}
+	if m.dir == "" {
+		return errors.New("fasthttpprometheus: MultiprocessGauge requires the Multiprocess option")
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("fasthttpprometheus: creating Multiprocess directory: %w", err)
+	}
+	return nil
+}
+
+func (p *Prometheus) multiprocessCollectors() []prometheus.Collector {
+	m := p.multiprocess
+	m.errs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("multiprocess_write_errors_total"),
+			Help:        "The failed writes of the metrics of the process to the Multiprocess directory.",
+		},
+	)
+	m.readErrs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("multiprocess_read_errors_total"),
+			Help:        "The files of the Multiprocess directory skipped by the scrapes because they could not be read.",
+		},
+	)
+
+	return []prometheus.Collector{m.errs, m.readErrs}
+}
+
+// aggregations returns the aggregations of the gauges by metric name, the
+// defaults of the middleware's process-level metrics overridden by the ones
+// set with MultiprocessGauge.
+func (p *Prometheus) aggregations() map[string]GaugeAggregation {
+	aggregations := map[string]GaugeAggregation{
+		p.fqName("build_info"):         Max,
+		p.fqName("uptime_seconds"):     Max,
+		p.fqName("start_time_seconds"): Min,
+	}
+	for name, aggregation := range p.multiprocess.gauges {
+		aggregations[name] = aggregation
+	}
+	return aggregations
+}
+
+// startMultiprocess starts writing the metrics of the process periodically,
+// if configured.
+func (p *Prometheus) startMultiprocess() {
+	m := p.multiprocess
+	if m == nil {
+		return
+	}
+
+	m.file = filepath.Join(m.dir, strconv.Itoa(os.Getpid())+multiprocessExt)
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			p.writeMultiprocess()
+			select {
+			case <-ticker.C:
+			case <-m.stop:
+				p.writeMultiprocess()
+				return
+			}
+		}
+	}()
+}
+
+// stopMultiprocess stops the writes started by startMultiprocess after a
+// final one.
+func (p *Prometheus) stopMultiprocess() {
+	m := p.multiprocess
+	if m == nil {
+		return
+	}
+
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// writeMultiprocess writes the metrics of the process to its file, replacing
+// it atomically so readers never see a partial write.
+func (p *Prometheus) writeMultiprocess() {
+	m := p.multiprocess
+	if err := m.write(p.own); err != nil {
+		m.errs.Inc()
+	}
+}
+
+func (m *multiprocess) write(g prometheus.Gatherer) error {
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(m.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := expfmt.NewEncoder(tmp, expfmt.FmtProtoDelim)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), m.file)
+}
+
+// gatherMultiprocess gathers the configured registry, replacing the metrics
+// of the middleware with their sum across the files of all the processes.
+// The metrics of this process are gathered live rather than read from its file.
+func (p *Prometheus) gatherMultiprocess() ([]*dto.MetricFamily, error) {
+	live, err := p.gatherer().Gather()
+	if err != nil {
+		return nil, err
+	}
+	own, err := p.own.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	m := p.multiprocess
+	agg := newAggregator(p.aggregations())
+	for _, mf := range own {
+		agg.add(mf, true)
+	}
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*"+multiprocessExt))
+	if err != nil {
+		return nil, err
+	}
+	stale := time.Now().Add(-multiprocessStale * m.interval)
+	for _, file := range files {
+		if file == m.file {
+			continue
+		}
+		if err := agg.addFile(file, stale); err != nil {
+			m.readErrs.Inc()
+		}
+	}
+
+	mfs := agg.families()
+	for _, mf := range live {
+		if _, ok := agg.byName[mf.GetName()]; !ok {
+			mfs = append(mfs, mf)
+		}
+	}
+	sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+
+	return mfs, nil
+}
+
+// aggregator sums metric families by name and label values, or aggregates
+// them as set in aggregations for the gauges and the other families named
+// there.
+type aggregator struct {
+	aggregations map[string]GaugeAggregation
+	byName       map[string]*dto.MetricFamily
+	metrics      map[string]map[string]*dto.Metric
+}
+
+func newAggregator(aggregations map[string]GaugeAggregation) *aggregator {
+	return &aggregator{
+		aggregations: aggregations,
+		byName:       map[string]*dto.MetricFamily{},
+		metrics:      map[string]map[string]*dto.Metric{},
+	}
+}
+
+// addFile adds the families of file, of a process still running unless it
+// was last written before stale. Nothing is added if file cannot be read.
+func (a *aggregator) addFile(file string, stale time.Time) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var mfs []*dto.MetricFamily
+	dec := expfmt.NewDecoder(f, expfmt.FmtProtoDelim)
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("fasthttpprometheus: reading %s: %w", file, err)
+		}
+		mfs = append(mfs, mf)
+	}
+
+	live := !info.ModTime().Before(stale)
+	for _, mf := range mfs {
+		a.add(mf, live)
+	}
+	return nil
+}
+
+// aggregation returns how the values of mf are aggregated.
+func (a *aggregator) aggregation(mf *dto.MetricFamily) GaugeAggregation {
+	if aggregation, ok := a.aggregations[mf.GetName()]; ok {
+		return aggregation
+	}
+	if mf.GetType() == dto.MetricType_GAUGE {
+		return LiveSum
+	}
+	return Sum
+}
+
+// add adds the values of mf, of a process still running if live.
+func (a *aggregator) add(mf *dto.MetricFamily, live bool) {
+	aggregation := a.aggregation(mf)
+	if !live && aggregation != Sum {
+		return
+	}
+
+	name := mf.GetName()
+	family, ok := a.byName[name]
+	if !ok {
+		family = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+		a.byName[name] = family
+		a.metrics[name] = map[string]*dto.Metric{}
+	} else if family.GetType() != mf.GetType() {
+		return
+	}
+
+	for _, m := range mf.GetMetric() {
+		key := labelsKey(m.GetLabel())
+		if sum, ok := a.metrics[name][key]; ok {
+			addMetric(sum, m, aggregation)
+			continue
+		}
+		sum := &dto.Metric{Label: m.Label}
+		initMetric(sum, m)
+		a.metrics[name][key] = sum
+	}
+}
+
+func (a *aggregator) families() []*dto.MetricFamily {
+	mfs := make([]*dto.MetricFamily, 0, len(a.byName))
+	for name, family := range a.byName {
+		keys := make([]string, 0, len(a.metrics[name]))
+		for key := range a.metrics[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		family.Metric = make([]*dto.Metric, 0, len(keys))
+		for _, key := range keys {
+			family.Metric = append(family.Metric, a.metrics[name][key])
+		}
+		mfs = append(mfs, family)
+	}
+	return mfs
+}
+
+func labelsKey(labels []*dto.LabelPair) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.GetName())
+		b.WriteByte('=')
+		b.WriteString(l.GetValue())
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// initMetric sets the values of sum to copies of the values of m, without
+// exemplars, summary quantiles and native histogram buckets.
+func initMetric(sum, m *dto.Metric) {
+	switch {
+	case m.Counter != nil:
+		sum.Counter = &dto.Counter{Value: float64Ptr(m.Counter.GetValue())}
+	case m.Gauge != nil:
+		sum.Gauge = &dto.Gauge{Value: float64Ptr(m.Gauge.GetValue())}
+	case m.Untyped != nil:
+		sum.Untyped = &dto.Untyped{Value: float64Ptr(m.Untyped.GetValue())}
+	case m.Summary != nil:
+		sum.Summary = &dto.Summary{
+			SampleCount: uint64Ptr(m.Summary.GetSampleCount()),
+			SampleSum:   float64Ptr(m.Summary.GetSampleSum()),
+		}
+	case m.Histogram != nil:
+		h := &dto.Histogram{
+			SampleCount: uint64Ptr(m.Histogram.GetSampleCount()),
+			SampleSum:   float64Ptr(m.Histogram.GetSampleSum()),
+		}
+		for _, b := range m.Histogram.GetBucket() {
+			h.Bucket = append(h.Bucket, &dto.Bucket{
+				UpperBound:      float64Ptr(b.GetUpperBound()),
+				CumulativeCount: uint64Ptr(b.GetCumulativeCount()),
+			})
+		}
+		sum.Histogram = h
+	}
+}
+
+// addMetric adds the values of m to sum, or keeps the greatest or least of the
+// values of counters, gauges and untyped metrics with Max and Min.
+func addMetric(sum, m *dto.Metric, aggregation GaugeAggregation) {
+	switch {
+	case sum.Counter != nil:
+		aggregate(sum.Counter.Value, m.GetCounter().GetValue(), aggregation)
+	case sum.Gauge != nil:
+		aggregate(sum.Gauge.Value, m.GetGauge().GetValue(), aggregation)
+	case sum.Untyped != nil:
+		aggregate(sum.Untyped.Value, m.GetUntyped().GetValue(), aggregation)
+	case sum.Summary != nil:
+		*sum.Summary.SampleCount += m.GetSummary().GetSampleCount()
+		*sum.Summary.SampleSum += m.GetSummary().GetSampleSum()
+	case sum.Histogram != nil:
+		*sum.Histogram.SampleCount += m.GetHistogram().GetSampleCount()
+		*sum.Histogram.SampleSum += m.GetHistogram().GetSampleSum()
+		buckets := m.GetHistogram().GetBucket()
+		for i, b := range sum.Histogram.Bucket {
+			if i < len(buckets) && buckets[i].GetUpperBound() == b.GetUpperBound() {
+				*b.CumulativeCount += buckets[i].GetCumulativeCount()
+			}
+		}
+	}
+}
+
+func aggregate(sum *float64, v float64, aggregation GaugeAggregation) {
+	switch aggregation {
+	case Max:
+		*sum = math.Max(*sum, v)
+	case Min:
+		*sum = math.Min(*sum, v)
+	default:
+		*sum += v
+	}
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func uint64Ptr(v uint64) *uint64 { return &v }
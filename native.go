@@ -0,0 +1,74 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type nativeHistogram struct {
+	bucketFactor     float64
+	zeroThreshold    float64
+	maxBuckets       uint32
+	minResetDuration time.Duration
+}
+
+// NativeHistograms is an option which makes the request duration histogram also a native histogram, scraped by
+// Prometheus 2.40 and later with the protobuf format, whose bucket boundaries grow by at most bucketFactor
+func NativeHistograms(bucketFactor float64, options ...func(*nativeHistogram)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		nh := &nativeHistogram{bucketFactor: bucketFactor}
+		for _, option := range options {
+			option(nh)
+		}
+		p.nativeHistogram = nh
+	}
+}
+
+// NativeZeroThreshold is a NativeHistograms option which sets the width of the bucket of the observations close to zero
+func NativeZeroThreshold(threshold float64) func(*nativeHistogram) {
+	return func(nh *nativeHistogram) {
+		nh.zeroThreshold = threshold
+	}
+}
+
+// NativeMaxBuckets is a NativeHistograms option which limits the number of buckets, and the minimum duration after which
+// the histogram is reset when the limit is exceeded, instead of its resolution being reduced
+func NativeMaxBuckets(n uint32, minResetDuration time.Duration) func(*nativeHistogram) {
+	return func(nh *nativeHistogram) {
+		nh.maxBuckets = n
+		nh.minResetDuration = minResetDuration
+	}
+}
+
+// apply sets the native histogram fields of opts.
+func (nh *nativeHistogram) apply(opts *prometheus.HistogramOpts) {
+	if nh == nil {
+		return
+	}
+
+	opts.NativeHistogramBucketFactor = nh.bucketFactor
+	opts.NativeHistogramZeroThreshold = nh.zeroThreshold
+	opts.NativeHistogramMaxBucketNumber = nh.maxBuckets
+	opts.NativeHistogramMinResetDuration = nh.minResetDuration
+}
+
+// validate checks the native histogram configuration.
+func (nh *nativeHistogram) validate() error {
+	if nh == nil {
+		return nil
+	}
+
+	if nh.bucketFactor <= 1 {
+		return fmt.Errorf("fasthttpprometheus: NativeHistograms bucket factor %v is not greater than 1", nh.bucketFactor)
+	}
+	if nh.zeroThreshold < 0 {
+		return fmt.Errorf("fasthttpprometheus: NativeZeroThreshold %v is negative", nh.zeroThreshold)
+	}
+	if nh.minResetDuration < 0 {
+		return fmt.Errorf("fasthttpprometheus: NativeMaxBuckets reset duration %v is negative", nh.minResetDuration)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package fasthttpprometheus
+
+import "time"
+
+// RequestStats are the measurements of a served request.
+type RequestStats struct {
+	// Code is the response status code.
+	Code int
+	// Method is the request method.
+	Method string
+	// Endpoint is the endpoint label value of the request.
+	Endpoint string
+	// Duration is the time spent serving the request.
+	Duration time.Duration
+	// RequestSize is the approximate size of the request in bytes.
+	RequestSize int
+	// ResponseSize is the size of the response body in bytes.
+	ResponseSize int
+	// TimeToFirstByte is the time until the response headers are sent,
+	// which is Duration unless the response body is streamed with
+	// SetBodyStream or SetBodyStreamWriter.
+	TimeToFirstByte time.Duration
+	// BodyReadDuration is the time the handler spent reading the request
+	// body with RequestBody or RequestBodyStream.
+	BodyReadDuration time.Duration
+	// ContentType is the media type of the response, without parameters.
+	ContentType string
+	// TraceID is the trace ID of the request with the TraceExemplars
+	// option, if any.
+	TraceID string
+	// Labels are the values of the labels added with the ExtraLabels
+	// option, by label name.
+	Labels map[string]string
+}
+
+// Observer records the measurements of served requests. *Prometheus is the
+// default Observer, to which more can be added with the Observers option.
+type Observer interface {
+	ObserveRequest(stats RequestStats)
+}
+
+// ObserverFunc is an adapter allowing the use of ordinary functions as
+// Observer.
+type ObserverFunc func(stats RequestStats)
+
+// ObserveRequest calls f(stats).
+func (f ObserverFunc) ObserveRequest(stats RequestStats) {
+	f(stats)
+}
+
+// Observers is an option which allows to add observers receiving the stats of every request along with the Prometheus collectors
+func Observers(observers ...Observer) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.observers = append(p.observers, observers...)
+	}
+}
+
+// observe passes stats to p and the added observers.
+func (p *Prometheus) observe(stats RequestStats) {
+	p.ObserveRequest(stats)
+
+	for _, o := range p.observers {
+		o.ObserveRequest(stats)
+	}
+}
@@ -0,0 +1,173 @@
+package fasthttpprometheus
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// phaseMetrics is the collector of the dialers of PhaseDialer, registered
+// with the first of them.
+type phaseMetrics struct {
+	register sync.Once
+
+	dur *prometheus.HistogramVec
+}
+
+// PhaseDialer returns a fasthttp.DialFunc, to be set as the Dial of a fasthttp.Client or HostClient, observing the
+// phases of the outbound requests in client_request_phase_duration_seconds by host and phase: dns, connect, tls and
+// ttfb, the time from sending a request to receiving the first byte of its response. With a non-nil tlsConfig, the
+// TLS handshake is performed by the dialer, so it should only be used for TLS hosts, e.g. with a HostClient with IsTLS.
+// Like fasthttp.Dial, it caches the resolved addresses for fasthttp.DefaultDNSCacheDuration, observing the dns phase
+// only when resolving them, and times out after fasthttp.DefaultDialTimeout, as the clients do not pass their timeouts
+// to their Dial
+func (p *Prometheus) PhaseDialer(tlsConfig *tls.Config) fasthttp.DialFunc {
+	return p.PhaseDialerTimeout(tlsConfig, fasthttp.DefaultDialTimeout)
+}
+
+// PhaseDialerTimeout is like PhaseDialer, but times out after timeout, like fasthttp.DialTimeout
+func (p *Prometheus) PhaseDialerTimeout(tlsConfig *tls.Config, timeout time.Duration) fasthttp.DialFunc {
+	m := p.phases
+	m.register.Do(func() {
+		p.registerCollectors(m.dur)
+	})
+
+	var dialer net.Dialer
+	var resolved sync.Map // host -> *phaseAddrs
+
+	return func(addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		observe := func(phase string, start time.Time) {
+			m.dur.WithLabelValues(host, phase).Observe(p.durationValue(time.Since(start)))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var addrs *phaseAddrs
+		if v, ok := resolved.Load(host); ok && time.Since(v.(*phaseAddrs).resolved) < fasthttp.DefaultDNSCacheDuration {
+			addrs = v.(*phaseAddrs)
+		} else {
+			start := time.Now()
+			ips, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			observe("dns", start)
+
+			addrs = &phaseAddrs{ips: ips, resolved: time.Now()}
+			resolved.Store(host, addrs)
+		}
+
+		start := time.Now()
+		var c net.Conn
+		// The addresses are dialed in turns, starting with the one after the
+		// first of the previous dial, like fasthttp.TCPDialer does.
+		next := atomic.AddUint32(&addrs.next, 1)
+		for i := range addrs.ips {
+			ip := addrs.ips[(int(next)+i)%len(addrs.ips)]
+			if c, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, port)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		observe("connect", start)
+
+		pc := &phaseConn{Conn: c, observe: observe}
+		if tlsConfig == nil {
+			return pc, nil
+		}
+
+		config := tlsConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = host
+		}
+		tc := tls.Client(c, config)
+
+		start = time.Now()
+		if err := tc.HandshakeContext(ctx); err != nil {
+			c.Close()
+			return nil, err
+		}
+		observe("tls", start)
+
+		pc.Conn = tc
+		return &phaseTLSConn{phaseConn: pc, tc: tc}, nil
+	}
+}
+
+// phaseAddrs are the resolved addresses of a host cached by PhaseDialer.
+type phaseAddrs struct {
+	ips      []string
+	resolved time.Time
+	next     uint32
+}
+
+// phaseConn observes the time from the last write of a request to the
+// first read of its response.
+type phaseConn struct {
+	net.Conn
+	observe func(phase string, start time.Time)
+
+	awaiting  bool
+	lastWrite time.Time
+}
+
+func (c *phaseConn) Write(b []byte) (int, error) {
+	c.awaiting = true
+	c.lastWrite = time.Now()
+	return c.Conn.Write(b)
+}
+
+func (c *phaseConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if c.awaiting && n > 0 {
+		c.awaiting = false
+		c.observe("ttfb", c.lastWrite)
+	}
+	return n, err
+}
+
+// phaseTLSConn implements Handshake and ConnectionState, so fasthttp does not
+// negotiate TLS again over the connection.
+type phaseTLSConn struct {
+	*phaseConn
+	tc *tls.Conn
+}
+
+func (c *phaseTLSConn) Handshake() error {
+	return c.tc.Handshake()
+}
+
+func (c *phaseTLSConn) ConnectionState() tls.ConnectionState {
+	return c.tc.ConnectionState()
+}
+
+// phaseCollectors creates the collector of PhaseDialer, which is not
+// registered until it is called.
+func (p *Prometheus) phaseCollectors() {
+	p.phases = &phaseMetrics{
+		dur: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				ConstLabels: p.constLabels,
+				Name:        p.name("client_request_phase_duration_seconds"),
+				Help:        "The duration of the phases of the HTTP requests of the client in " + p.durationUnit.String() + ".",
+				Buckets:     p.durationBuckets([]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}),
+			},
+			[]string{"host", "phase"},
+		),
+	}
+}
@@ -0,0 +1,170 @@
+package fasthttpprometheus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxPreInitializedSeries bounds the number of series PreInitialize may create
+// per collector, guarding against accidentally large combinations.
+const maxPreInitializedSeries = 10000
+
+type preInit struct {
+	codes     []string
+	methods   []string
+	endpoints []string
+
+	routes     bool
+	routeCodes []string
+	router     RouteLister
+	// routeSeries are the code, method and path of the series of the routes,
+	// listed by validate with PreInitializeRouter, or by WrapHandler.
+	routeSeries []routeSeries
+}
+
+type routeSeries struct{ code, method, path string }
+
+// RouteLister is implemented by routers able to list their registered routes
+// grouped by method, such as *router.Router of github.com/fasthttp/router.
+type RouteLister interface {
+	List() map[string][]string
+}
+
+// PreInitialize is an option which creates zero-valued series for every combination of the given codes, methods and the endpoints set with PreInitializeEndpoints
+func PreInitialize(codes []string, methods []string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if p.preInit == nil {
+			p.preInit = &preInit{}
+		}
+		p.preInit.codes = codes
+		p.preInit.methods = methods
+	}
+}
+
+// PreInitializeEndpoints is an option which sets the endpoints used by PreInitialize
+func PreInitializeEndpoints(endpoints ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if p.preInit == nil {
+			p.preInit = &preInit{}
+		}
+		p.preInit.endpoints = append(p.preInit.endpoints, endpoints...)
+	}
+}
+
+// PreInitializeRoutes is an option which creates zero-valued series for every route registered on a RouteLister router
+// when it is wrapped, for each of the given codes. Without codes, the status classes 2xx to 5xx are used with StatusClass,
+// and 200 and 500 otherwise. Routes with parameters are only initialized with RouteTemplates. As WrapHandler cannot
+// return an error, no series are created if there would be more than 10000 of them; use PreInitializeRouter to get the
+// error from NewPrometheusWithError instead
+func PreInitializeRoutes(codes ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if p.preInit == nil {
+			p.preInit = &preInit{}
+		}
+		p.preInit.routes = true
+		p.preInit.routeCodes = codes
+	}
+}
+
+// PreInitializeRouter is an option which creates the series of PreInitializeRoutes for the routes registered on r when
+// the middleware is created rather than when it is wrapped, so NewPrometheusWithError reports more than 10000 of them
+func PreInitializeRouter(r RouteLister) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if r == nil {
+			p.optionErrs = append(p.optionErrs, errors.New("fasthttpprometheus: PreInitializeRouter router is nil"))
+			return
+		}
+		if p.preInit == nil {
+			p.preInit = &preInit{}
+		}
+		p.preInit.routes = true
+		p.preInit.router = r
+	}
+}
+
+// listRouteSeries returns the series of PreInitializeRoutes for the routes of
+// lister, or an error if there are more than maxPreInitializedSeries.
+func (p *Prometheus) listRouteSeries(lister RouteLister) ([]routeSeries, error) {
+	codes := p.preInit.routeCodes
+	if len(codes) == 0 {
+		codes = []string{"200", "500"}
+		if p.statusClass {
+			codes = []string{"2xx", "3xx", "4xx", "5xx"}
+		}
+	}
+
+	var series []routeSeries
+	for method, paths := range lister.List() {
+		for _, path := range paths {
+			if p.isMetricsPath(path) || (!p.routeTemplates && strings.ContainsAny(path, "{:*")) {
+				continue
+			}
+			for _, code := range codes {
+				series = append(series, routeSeries{code, method, path})
+			}
+		}
+	}
+
+	if n := len(series); n > maxPreInitializedSeries {
+		return nil, fmt.Errorf("fasthttpprometheus: PreInitializeRoutes would create %d series, more than the limit of %d", n, maxPreInitializedSeries)
+	}
+	return series, nil
+}
+
+// preInitializeRoutes creates the zero-valued children of the routes of r
+// configured with PreInitializeRoutes, unless they were listed with
+// PreInitializeRouter or there would be more than maxPreInitializedSeries.
+func (p *Prometheus) preInitializeRoutes(r Router) {
+	pi := p.preInit
+	if pi == nil || !pi.routes || pi.router != nil {
+		return
+	}
+	lister, ok := r.(RouteLister)
+	if !ok {
+		return
+	}
+
+	series, err := p.listRouteSeries(lister)
+	if err != nil {
+		return
+	}
+
+	pi.routeSeries = series
+	for _, s := range series {
+		p.preInitializeSeries(s.code, s.method, s.path)
+	}
+}
+
+// preInitialize creates the zero-valued children configured with PreInitialize,
+// and those of the routes listed so far. The number of combinations is checked
+// against maxPreInitializedSeries by validate.
+func (p *Prometheus) preInitialize() {
+	pi := p.preInit
+	if pi == nil {
+		return
+	}
+
+	for _, s := range pi.routeSeries {
+		p.preInitializeSeries(s.code, s.method, s.path)
+	}
+
+	for _, code := range pi.codes {
+		for _, method := range pi.methods {
+			for _, endpoint := range pi.endpoints {
+				p.preInitializeSeries(code, method, endpoint)
+			}
+		}
+	}
+}
+
+// preInitializeSeries creates the zero-valued children for code, method and
+// endpoint, rewritten like the endpoints of the requests.
+func (p *Prometheus) preInitializeSeries(code, method, endpoint string) {
+	endpoint = p.normalizeEndpoint(nil, endpoint)
+	lvs := p.labelValues(code, method, endpoint, nil)
+	p.reqCnt.WithLabelValues(lvs...)
+	p.durationVec(endpoint).WithLabelValues(lvs...)
+	p.trackSeries("requests_total", lvs...)
+	p.trackSeries("request_duration_seconds", lvs...)
+}
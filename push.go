@@ -0,0 +1,116 @@
+package fasthttpprometheus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+type pushGateway struct {
+	url, job string
+	interval time.Duration
+
+	pusher *push.Pusher
+	errs   prometheus.Counter
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// PushGateway is an option which periodically pushes the registry to the Pushgateway at url under jobName in a background goroutine.
+// With a zero interval, the registry is only pushed by PushOnce and Shutdown
+func PushGateway(url, jobName string, interval time.Duration) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.pushGateway = &pushGateway{url: url, job: jobName, interval: interval}
+	}
+}
+
+// PushOnce immediately pushes the registry to the Pushgateway configured
+// with the PushGateway option. It is a no-op without it.
+func (p *Prometheus) PushOnce() error {
+	return p.pushOnce(context.Background())
+}
+
+func (p *Prometheus) pushOnce(ctx context.Context) error {
+	if p.pushGateway == nil {
+		return nil
+	}
+
+	return p.pushGateway.push(ctx)
+}
+
+// StopPush stops the periodic pushes started by the PushGateway option,
+// waiting for a push in progress to complete. It does not push again; call
+// PushOnce afterwards to push the final state, or use Shutdown.
+func (p *Prometheus) StopPush() {
+	if p.pushGateway == nil {
+		return
+	}
+
+	pg := p.pushGateway
+	pg.stopOnce.Do(func() { close(pg.stop) })
+	<-pg.done
+}
+
+func (pg *pushGateway) push(ctx context.Context) error {
+	err := pg.pusher.PushContext(ctx)
+	if err != nil {
+		pg.errs.Inc()
+	}
+	return err
+}
+
+func (pg *pushGateway) run() {
+	defer close(pg.done)
+
+	ticker := time.NewTicker(pg.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Failures are counted in push_errors_total and retried on
+			// the next tick.
+			_ = pg.push(context.Background())
+		case <-pg.stop:
+			return
+		}
+	}
+}
+
+// startPush starts pushing the registry periodically, if configured.
+func (p *Prometheus) startPush() {
+	pg := p.pushGateway
+	if pg == nil {
+		return
+	}
+
+	pg.pusher = push.New(pg.url, pg.job).Gatherer(p.gatherer())
+	pg.stop = make(chan struct{})
+	pg.done = make(chan struct{})
+
+	if pg.interval == 0 {
+		close(pg.done)
+		return
+	}
+
+	go pg.run()
+}
+
+func (p *Prometheus) pushCollector() prometheus.Collector {
+	p.pushGateway.errs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("push_errors_total"),
+			Help:        "The failed pushes to the Pushgateway.",
+		},
+	)
+
+	return p.pushGateway.errs
+}
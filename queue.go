@@ -0,0 +1,86 @@
+package fasthttpprometheus
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// defaultQueueHeader is the header stamped by load balancers with the time
+// they received the request.
+const defaultQueueHeader = "X-Request-Start"
+
+// QueueDuration is an option which enables the request_queue_duration_seconds histogram of the time from the load
+// balancer receiving a request, as stamped in the named header, X-Request-Start by default, until its handling starts.
+// The header value is a unix timestamp in seconds, milliseconds or microseconds, optionally prefixed with t=
+func QueueDuration(header ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.queueHeader = defaultQueueHeader
+		if len(header) > 0 {
+			p.queueHeader = header[0]
+		}
+	}
+}
+
+// observeQueueDuration observes the time since the request in ctx was stamped
+// by the load balancer, with the QueueDuration option.
+func (p *Prometheus) observeQueueDuration(ctx *fasthttp.RequestCtx) {
+	if p.queueDur == nil {
+		return
+	}
+
+	stamped, ok := parseRequestStart(ctx.Request.Header.Peek(p.queueHeader))
+	if !ok {
+		return
+	}
+
+	d := time.Since(stamped)
+	if d < 0 {
+		// The clocks of the load balancer and the server are skewed.
+		d = 0
+	}
+	p.queueDur.WithLabelValues().Observe(p.durationValue(d))
+}
+
+// parseRequestStart parses an X-Request-Start header value, telling the unit
+// of the timestamp from its magnitude.
+func parseRequestStart(value []byte) (time.Time, bool) {
+	value = bytes.TrimPrefix(bytes.TrimSpace(value), []byte("t="))
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+
+	ts, err := strconv.ParseFloat(string(value), 64)
+	if err != nil || ts <= 0 {
+		return time.Time{}, false
+	}
+
+	switch {
+	case ts > 1e15:
+		ts /= 1e6
+	case ts > 1e12:
+		ts /= 1e3
+	}
+
+	sec := int64(ts)
+	return time.Unix(sec, int64((ts-float64(sec))*1e9)), true
+}
+
+func (p *Prometheus) queueDurationCollector() prometheus.Collector {
+	p.queueDur = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("request_queue_duration_seconds"),
+			Help:        "The time from the load balancer receiving an HTTP request to handling it in " + p.durationUnit.String() + ".",
+			Buckets:     p.durationBuckets([]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}),
+		},
+		nil,
+	)
+
+	return p.queueDur
+}
@@ -0,0 +1,54 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// RecoverPanics is an option which recovers the panics of the instrumented handler, answering the request with 500
+// and counting it in panics_total, instead of letting them bypass the instrumentation
+func RecoverPanics() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.recoverPanics = true
+	}
+}
+
+// serve calls next with ctx, recovering its panics with the RecoverPanics
+// option. endpoint is only called when a panic is counted.
+func (p *Prometheus) serve(ctx *fasthttp.RequestCtx, next fasthttp.RequestHandler, method string, endpoint func() string) {
+	if !p.recoverPanics {
+		next(ctx)
+		return
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		e := endpoint()
+		p.panics.WithLabelValues(e, method).Inc()
+		p.trackSeries("panics_total", e, method)
+
+		ctx.Logger().Printf("panic serving %s %s: %v", method, ctx.Path(), r)
+		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusInternalServerError), fasthttp.StatusInternalServerError)
+	}()
+
+	next(ctx)
+}
+
+func (p *Prometheus) panicCollector() prometheus.Collector {
+	p.panics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("panics_total"),
+			Help:        "The panics recovered from the HTTP request handler.",
+		},
+		[]string{"endpoint", "method"},
+	)
+
+	return p.panics
+}
@@ -0,0 +1,20 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (p *Prometheus) routerRedirectCollector() prometheus.Collector {
+	p.routerRedirects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("router_redirects_total"),
+			Help:        "The HTTP requests redirected by the router to the canonical route path.",
+		},
+		[]string{"kind"},
+	)
+
+	return p.routerRedirects
+}
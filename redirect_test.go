@@ -0,0 +1,84 @@
+package fasthttpprometheus
+
+import (
+	"testing"
+
+	"github.com/buaazp/fasthttprouter"
+	"github.com/fasthttp/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+)
+
+// serve serves a request for method and uri with h and returns its context.
+func serve(h fasthttp.RequestHandler, method, uri string) *fasthttp.RequestCtx {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	h(&ctx)
+	return &ctx
+}
+
+func TestRouterRedirects(t *testing.T) {
+	noop := func(*fasthttp.RequestCtx) {}
+	moved := func(ctx *fasthttp.RequestCtx) {
+		ctx.Redirect("/new", fasthttp.StatusMovedPermanently)
+	}
+
+	routers := []struct {
+		name   string
+		router func() Router
+	}{
+		{"fasthttprouter", func() Router {
+			r := fasthttprouter.New()
+			r.GET("/users/", noop)
+			r.GET("/docs", noop)
+			r.GET("/old", moved)
+			return r
+		}},
+		{"fasthttp/router", func() Router {
+			r := router.New()
+			r.GET("/users/", noop)
+			r.GET("/docs", noop)
+			r.GET("/old", moved)
+			return r
+		}},
+	}
+
+	tests := []struct {
+		path     string
+		kind     string
+		endpoint string
+	}{
+		{"/users", "trailing_slash", "/users/"},
+		{"/DOCS", "fixed_path", "/docs"},
+		{"/old", "", "/old"},
+	}
+
+	for _, rt := range routers {
+		for _, tt := range tests {
+			t.Run(rt.name+tt.path, func(t *testing.T) {
+				p := NewPrometheus(Registry(prometheus.NewRegistry()))
+				ctx := serve(p.WrapHandler(rt.router()), fasthttp.MethodGet, tt.path)
+
+				if code := ctx.Response.StatusCode(); code != fasthttp.StatusMovedPermanently {
+					t.Fatalf("status %d, want %d", code, fasthttp.StatusMovedPermanently)
+				}
+
+				for _, kind := range []string{"trailing_slash", "fixed_path"} {
+					want := 0.
+					if kind == tt.kind {
+						want = 1
+					}
+					if got := testutil.ToFloat64(p.routerRedirects.WithLabelValues(kind)); got != want {
+						t.Errorf("router_redirects_total{kind=%q} = %v, want %v", kind, got, want)
+					}
+				}
+
+				if got := testutil.ToFloat64(p.reqCnt.WithLabelValues("301", fasthttp.MethodGet, tt.endpoint)); got != 1 {
+					t.Errorf("requests_total{endpoint=%q} = %v, want 1", tt.endpoint, got)
+				}
+			})
+		}
+	}
+}
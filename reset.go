@@ -0,0 +1,203 @@
+package fasthttpprometheus
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Reset clears the values collected by the middleware's request metrics, e.g.
+// between integration test scenarios, without registering them again. The
+// concurrent requests gauge, reflecting the requests in flight, is kept.
+func (p *Prometheus) Reset() {
+	defer p.children.clear()
+	p.reqCnt.Reset()
+	for _, vec := range p.durationVecs() {
+		vec.Reset()
+	}
+	if p.reqSize != nil {
+		p.reqSize.Reset()
+	}
+	if p.reqSizeHist != nil {
+		p.reqSizeHist.Reset()
+	}
+	if p.respSize != nil {
+		p.respSize.Reset()
+	}
+	if p.respSizeHist != nil {
+		p.respSizeHist.Reset()
+	}
+	p.serverLogErrs.Reset()
+	p.serverErrs.Reset()
+	p.hijacked.Reset()
+	p.routerRedirects.Reset()
+
+	if p.readDur != nil {
+		p.readDur.Reset()
+	}
+	if p.queueDur != nil {
+		p.queueDur.Reset()
+	}
+	if p.connRequests != nil {
+		p.connRequests.Reset()
+	}
+	if p.panics != nil {
+		p.panics.Reset()
+	}
+	if p.unmatched != nil {
+		p.unmatched.Reset()
+	}
+	if p.ttfbDur != nil {
+		p.ttfbDur.Reset()
+	}
+	if p.bodyReadDur != nil {
+		p.bodyReadDur.Reset()
+	}
+	if p.slow != nil {
+		p.slow.counter.Reset()
+	}
+	if p.latencySLO != nil {
+		p.latencySLO.within.Reset()
+	}
+	if p.apdex != nil {
+		p.apdex.counter.Reset()
+	}
+	if p.bytes != nil {
+		p.bytes.in.Reset()
+		p.bytes.out.Reset()
+	}
+	if p.deadline != nil {
+		p.deadline.expired.Reset()
+		p.deadline.invalid.Reset()
+	}
+
+	for _, t := range p.series {
+		t.reset()
+	}
+	if p.expiry != nil {
+		p.expiry.remove(func(*expiryEntry) bool { return true })
+	}
+	if p.endpointLimiter != nil {
+		p.endpointLimiter.reset()
+	}
+
+	p.initUnlabeled()
+	p.preInitialize()
+}
+
+// DeleteEndpoint deletes the series recorded for the endpoint label value
+// endpoint, e.g. after unregistering its route, and returns the number of
+// series deleted.
+func (p *Prometheus) DeleteEndpoint(endpoint string) int {
+	labels := prometheus.Labels{"endpoint": endpoint}
+	defer p.children.clear()
+
+	n := p.reqCnt.DeletePartialMatch(labels)
+	n += p.durationVec(endpoint).DeletePartialMatch(labels)
+	p.forgetEndpoint("requests_total", 2, endpoint)
+	p.forgetEndpoint("request_duration_seconds", 2, endpoint)
+	n += p.hijacked.DeletePartialMatch(labels)
+	p.forgetEndpoint("hijacked_requests_total", 1, endpoint)
+
+	if p.deadline != nil {
+		n += p.deadline.expired.DeletePartialMatch(labels)
+		n += p.deadline.invalid.DeletePartialMatch(labels)
+		p.forgetEndpoint("requests_expired_on_arrival_total", 0, endpoint)
+		p.forgetEndpoint("requests_deadline_invalid_total", 0, endpoint)
+	}
+
+	if p.reqSizeHist != nil {
+		n += p.reqSizeHist.DeletePartialMatch(labels)
+		p.forgetEndpoint("request_size_bytes", 1, endpoint)
+	}
+
+	if p.respSizeHist != nil {
+		n += p.respSizeHist.DeletePartialMatch(labels)
+		p.forgetEndpoint("response_size_bytes", 2, endpoint)
+	}
+
+	if p.ttfbDur != nil {
+		n += p.ttfbDur.DeletePartialMatch(labels)
+		p.forgetEndpoint("response_ttfb_seconds", 1, endpoint)
+	}
+
+	if p.bodyReadDur != nil {
+		n += p.bodyReadDur.DeletePartialMatch(labels)
+		p.forgetEndpoint("request_body_read_duration_seconds", 1, endpoint)
+	}
+
+	if p.panics != nil {
+		n += p.panics.DeletePartialMatch(labels)
+		p.forgetEndpoint("panics_total", 0, endpoint)
+	}
+
+	if p.bytes != nil && p.bytes.byEndpoint {
+		n += p.bytes.in.DeletePartialMatch(labels)
+		n += p.bytes.out.DeletePartialMatch(labels)
+		p.forgetEndpoint("request_bytes_total", 0, endpoint)
+		p.forgetEndpoint("response_bytes_total", 0, endpoint)
+	}
+
+	if p.apdex != nil {
+		n += p.apdex.counter.DeletePartialMatch(labels)
+		p.forgetEndpoint("apdex_requests_total", 0, endpoint)
+	}
+
+	if p.latencySLO != nil {
+		n += p.latencySLO.within.DeletePartialMatch(labels)
+		p.forgetEndpoint("requests_within_slo_total", 0, endpoint)
+	}
+
+	if p.slow != nil {
+		n += p.slow.counter.DeletePartialMatch(labels)
+		p.forgetEndpoint("slow_requests_total", 0, endpoint)
+	}
+
+	if p.openStreams != nil {
+		n += p.openStreams.DeletePartialMatch(labels)
+		p.forgetEndpoint("open_streams", 0, endpoint)
+	}
+
+	if p.inFlight != nil {
+		n += p.inFlight.DeletePartialMatch(labels)
+		p.forgetEndpoint("requests_in_flight", 0, endpoint)
+	}
+
+	if p.expiry != nil {
+		p.expiry.remove(func(entry *expiryEntry) bool { return entry.lvs[2] == endpoint })
+	}
+	if p.endpointLimiter != nil {
+		p.endpointLimiter.forget(endpoint)
+	}
+
+	return n
+}
+
+// forgetEndpoint forgets the tracked series of the collector named name whose
+// endpoint label, at index, is endpoint.
+func (p *Prometheus) forgetEndpoint(name string, index int, endpoint string) {
+	if t, ok := p.series[name]; ok {
+		t.forgetValue(index, endpoint)
+	}
+}
+
+// initUnlabeled creates the single child of the unlabeled metrics, which are
+// vectors without labels so they can be reset, so they are exposed before
+// their first observation.
+func (p *Prometheus) initUnlabeled() {
+	if p.reqSize != nil {
+		p.reqSize.WithLabelValues()
+	}
+	if p.respSize != nil && !p.contentTypeLabel {
+		p.respSize.WithLabelValues()
+	}
+	if p.readDur != nil {
+		p.readDur.WithLabelValues()
+	}
+	if p.queueDur != nil {
+		p.queueDur.WithLabelValues()
+	}
+	if p.connRequests != nil {
+		p.connRequests.WithLabelValues()
+	}
+	if p.bytes != nil && !p.bytes.byEndpoint {
+		p.bytes.in.WithLabelValues()
+		p.bytes.out.WithLabelValues()
+	}
+}
@@ -0,0 +1,155 @@
+package fasthttpprometheus
+
+import (
+	"github.com/buaazp/fasthttprouter"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// Router is the interface of the routers instrumented by WrapHandler, such as
+// *fasthttprouter.Router and *router.Router of github.com/fasthttp/router.
+type Router interface {
+	Handler(ctx *fasthttp.RequestCtx)
+	GET(path string, handler fasthttp.RequestHandler)
+}
+
+// RouteMatcher is implemented by routers able to report the pattern of the
+// route which served a request, recorded as the endpoint label with the
+// RouteTemplates option.
+type RouteMatcher interface {
+	MatchedRoute(ctx *fasthttp.RequestCtx) string
+}
+
+// routing gives the instrumentation access to the routes of the router
+// behind an instrumented handler.
+type routing interface {
+	// pattern returns the pattern of the route matching method and path, or
+	// "" if it is not known.
+	pattern(method, path string) string
+	// matched returns the pattern of the route which served ctx, or "" if it
+	// is not known.
+	matched(ctx *fasthttp.RequestCtx) string
+	// redirect reports whether the response in ctx is a trailing slash or
+	// fixed path redirect issued by the router itself rather than by a route
+	// handler, and returns its kind along with the path it redirects to.
+	redirect(ctx *fasthttp.RequestCtx) (kind, target string, ok bool)
+	// unmatched reports whether the request in ctx matched none of the
+	// routes of the router.
+	unmatched(ctx *fasthttp.RequestCtx) bool
+}
+
+// routingOf returns the routing of r, or nil if it is not known.
+func routingOf(r Router) routing {
+	switch r := r.(type) {
+	case *fasthttprouter.Router:
+		return routerRouting{r}
+	case *router.Router:
+		return fastRouterRouting{r}
+	case RouteMatcher:
+		return matcherRouting{r}
+	}
+
+	return nil
+}
+
+// matcherRouting gives access to the routes of a RouteMatcher.
+type matcherRouting struct {
+	m RouteMatcher
+}
+
+func (matcherRouting) pattern(method, path string) string {
+	return ""
+}
+
+func (rt matcherRouting) matched(ctx *fasthttp.RequestCtx) string {
+	return rt.m.MatchedRoute(ctx)
+}
+
+func (matcherRouting) redirect(ctx *fasthttp.RequestCtx) (kind, target string, ok bool) {
+	return "", "", false
+}
+
+func (rt matcherRouting) unmatched(ctx *fasthttp.RequestCtx) bool {
+	return rt.m.MatchedRoute(ctx) == "" && unmatchedStatus(ctx.Response.StatusCode())
+}
+
+// EndpointLabelFn is an option which allows to set a function returning the endpoint label value of each request,
+// instead of the request path or route template
+func EndpointLabelFn(fn func(*fasthttp.RequestCtx) string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.endpointFn = fn
+	}
+}
+
+// RouteTemplates is an option which records the matched route pattern, e.g. /users/:id, as the endpoint label instead of the request path
+func RouteTemplates() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.routeTemplates = true
+	}
+}
+
+// endpoint returns the endpoint label value of the request to path served
+// with ctx.
+func (p *Prometheus) endpoint(rt routing, ctx *fasthttp.RequestCtx, path string) string {
+	if p.endpointFn != nil {
+		return p.endpointFn(ctx)
+	}
+
+	if p.routeTemplates && rt != nil {
+		if route := rt.matched(ctx); route != "" {
+			return route
+		}
+	}
+
+	return path
+}
+
+// endpointOf returns the endpoint label value of a request for method and
+// path which has not been served.
+func (p *Prometheus) endpointOf(rt routing, method, path string) string {
+	if p.routeTemplates && rt != nil {
+		if route := rt.pattern(method, path); route != "" {
+			return route
+		}
+	}
+
+	return path
+}
+
+// unmatchedStatus reports whether code is the status of a request matching no
+// route, for routers unable to tell otherwise.
+func unmatchedStatus(code int) bool {
+	return code == fasthttp.StatusNotFound || code == fasthttp.StatusMethodNotAllowed
+}
+
+// routerRedirect implements routing.redirect for routers whose lookup
+// reports the route found for method and path along with whether a trailing
+// slash redirect applies.
+func routerRedirect(ctx *fasthttp.RequestCtx, lookup func(method, path string) (found, tsr bool), trailingSlash bool) (kind, target string, ok bool) {
+	switch ctx.Response.StatusCode() {
+	case fasthttp.StatusMovedPermanently, fasthttp.StatusTemporaryRedirect, fasthttp.StatusPermanentRedirect:
+	default:
+		return "", "", false
+	}
+
+	found, tsr := lookup(string(ctx.Method()), string(ctx.Path()))
+	if found {
+		return "", "", false
+	}
+
+	location := ctx.Response.Header.Peek(fasthttp.HeaderLocation)
+	if len(location) == 0 {
+		return "", "", false
+	}
+
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+	u.UpdateBytes(location)
+
+	kind = "fixed_path"
+	if tsr && trailingSlash {
+		kind = "trailing_slash"
+	}
+
+	return kind, string(u.Path()), true
+}
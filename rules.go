@@ -0,0 +1,225 @@
+package fasthttpprometheus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RuleOpts configures the rules generated by PrometheusRules.
+type RuleOpts struct {
+	// GroupName is the name of the rule group, "fasthttp" by default.
+	GroupName string
+	// Window is the range used by rate(), "5m" by default.
+	Window string
+	// Quantiles are the latency quantiles recorded from the duration
+	// histogram, 0.5, 0.9 and 0.99 by default.
+	Quantiles []float64
+	// SLOObjective enables multi-window burn-rate alerts for the given
+	// availability objective, e.g. 0.999. Zero disables the alerts.
+	SLOObjective float64
+
+	// ErrorRatioThreshold is the ratio of 5xx responses of an endpoint
+	// above which GenerateRules alerts, 0.05 by default.
+	ErrorRatioThreshold float64
+	// LatencyThreshold is the p99 latency of an endpoint above which
+	// GenerateRules alerts, 1s by default.
+	LatencyThreshold time.Duration
+	// SaturationThreshold is the number of concurrent requests above which
+	// GenerateRules alerts. Zero disables the alert.
+	SaturationThreshold float64
+	// For is how long the conditions of the GenerateRules alerts must hold
+	// before they fire, "10m" by default.
+	For string
+}
+
+// burnRates are the multi-window, multi-burn-rate alert thresholds
+// recommended by the Google SRE workbook.
+var burnRates = []struct {
+	alert       string
+	severity    string
+	long, short string
+	factor      float64
+}{
+	{"ErrorBudgetBurnFast", "page", "1h", "5m", 14.4},
+	{"ErrorBudgetBurnSlow", "ticket", "6h", "30m", 6},
+}
+
+// PrometheusRules renders a Prometheus rules file with recording rules for the
+// request rate, error ratio and latency quantiles of the configured metrics,
+// plus burn-rate alerts when an SLO objective is set.
+func (p *Prometheus) PrometheusRules(opts RuleOpts) ([]byte, error) {
+	if opts.GroupName == "" {
+		opts.GroupName = "fasthttp"
+	}
+	if opts.Window == "" {
+		opts.Window = "5m"
+	}
+	if opts.Quantiles == nil {
+		opts.Quantiles = []float64{.5, .9, .99}
+	}
+
+	for _, q := range opts.Quantiles {
+		if q <= 0 || q >= 1 {
+			return nil, fmt.Errorf("fasthttpprometheus: quantile %v is not between 0 and 1", q)
+		}
+	}
+	if opts.SLOObjective != 0 && (opts.SLOObjective <= 0 || opts.SLOObjective >= 1) {
+		return nil, errors.New("fasthttpprometheus: SLO objective must be between 0 and 1")
+	}
+
+	reqs := p.fqName("requests_total")
+	dur := p.fqName("request_duration_seconds")
+
+	var b strings.Builder
+	b.WriteString("groups:\n")
+	fmt.Fprintf(&b, "- name: %s\n", opts.GroupName)
+	b.WriteString("  rules:\n")
+
+	writeRecord := func(record, expr string) {
+		fmt.Fprintf(&b, "  - record: %s\n", record)
+		fmt.Fprintf(&b, "    expr: %s\n", quoteRule(expr))
+	}
+
+	writeRecord(
+		fmt.Sprintf("endpoint_method:%s:rate%s", reqs, opts.Window),
+		fmt.Sprintf("sum by (endpoint, method) (rate(%s[%s]))", reqs, opts.Window),
+	)
+	writeRecord(
+		fmt.Sprintf("endpoint:%s:error_ratio_rate%s", reqs, opts.Window),
+		errorRatio(reqs, "endpoint", opts.Window),
+	)
+	for _, q := range opts.Quantiles {
+		writeRecord(
+			fmt.Sprintf("endpoint:%s:%s_rate%s", dur, quantileName(q), opts.Window),
+			fmt.Sprintf("histogram_quantile(%s, sum by (endpoint, le) (rate(%s_bucket[%s])))",
+				strconv.FormatFloat(q, 'f', -1, 64), dur, opts.Window),
+		)
+	}
+
+	if opts.SLOObjective == 0 {
+		return []byte(b.String()), nil
+	}
+
+	windows := []string{}
+	for _, br := range burnRates {
+		windows = append(windows, br.short, br.long)
+	}
+	for _, w := range windows {
+		writeRecord(fmt.Sprintf("job:%s:error_ratio_rate%s", reqs, w), errorRatio(reqs, "job", w))
+	}
+
+	budget := 1 - opts.SLOObjective
+	for _, br := range burnRates {
+		threshold := strconv.FormatFloat(br.factor*budget, 'g', 6, 64)
+		fmt.Fprintf(&b, "  - alert: %s\n", br.alert)
+		fmt.Fprintf(&b, "    expr: %s\n", quoteRule(fmt.Sprintf(
+			"job:%[1]s:error_ratio_rate%[2]s > %[4]s and job:%[1]s:error_ratio_rate%[3]s > %[4]s",
+			reqs, br.long, br.short, threshold)))
+		b.WriteString("    labels:\n")
+		fmt.Fprintf(&b, "      severity: %s\n", br.severity)
+		b.WriteString("    annotations:\n")
+		fmt.Fprintf(&b, "      summary: %s\n", quoteRule(fmt.Sprintf(
+			"Error budget for {{ $labels.job }} is burning %vx faster than allowed by the %v objective.",
+			br.factor, opts.SLOObjective)))
+	}
+
+	return []byte(b.String()), nil
+}
+
+// GenerateRules renders a Prometheus rules file with the rules of
+// PrometheusRules plus alerts on the error ratio and p99 latency of each
+// endpoint, and on the concurrent requests with a SaturationThreshold.
+func (p *Prometheus) GenerateRules(opts RuleOpts) ([]byte, error) {
+	if opts.ErrorRatioThreshold == 0 {
+		opts.ErrorRatioThreshold = .05
+	}
+	if opts.LatencyThreshold == 0 {
+		opts.LatencyThreshold = time.Second
+	}
+	if opts.For == "" {
+		opts.For = "10m"
+	}
+
+	if opts.ErrorRatioThreshold <= 0 || opts.ErrorRatioThreshold >= 1 {
+		return nil, fmt.Errorf("fasthttpprometheus: error ratio threshold %v is not between 0 and 1", opts.ErrorRatioThreshold)
+	}
+	if opts.LatencyThreshold < 0 {
+		return nil, fmt.Errorf("fasthttpprometheus: latency threshold %v is negative", opts.LatencyThreshold)
+	}
+	if opts.SaturationThreshold < 0 {
+		return nil, fmt.Errorf("fasthttpprometheus: saturation threshold %v is negative", opts.SaturationThreshold)
+	}
+
+	rules, err := p.PrometheusRules(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := p.fqName("requests_total")
+	dur := p.fqName("request_duration_seconds")
+	window := opts.Window
+	if window == "" {
+		window = "5m"
+	}
+
+	b := bytes.NewBuffer(rules)
+	writeAlert := func(alert, expr, summary string) {
+		fmt.Fprintf(b, "  - alert: %s\n", alert)
+		fmt.Fprintf(b, "    expr: %s\n", quoteRule(expr))
+		fmt.Fprintf(b, "    for: %s\n", opts.For)
+		b.WriteString("    labels:\n")
+		b.WriteString("      severity: warning\n")
+		b.WriteString("    annotations:\n")
+		fmt.Fprintf(b, "      summary: %s\n", quoteRule(summary))
+	}
+
+	errorThreshold := strconv.FormatFloat(opts.ErrorRatioThreshold, 'g', 6, 64)
+	writeAlert("HTTPHighErrorRatio",
+		fmt.Sprintf("endpoint:%s:error_ratio_rate%s > %s", reqs, window, errorThreshold),
+		fmt.Sprintf("More than %s of the requests to {{ $labels.endpoint }} fail.", errorThreshold))
+
+	latencyThreshold := strconv.FormatFloat(p.durationValue(opts.LatencyThreshold), 'g', 6, 64)
+	writeAlert("HTTPHighLatency",
+		fmt.Sprintf("histogram_quantile(0.99, sum by (endpoint, le) (rate(%s_bucket[%s]))) > %s", dur, window, latencyThreshold),
+		fmt.Sprintf("The p99 latency of {{ $labels.endpoint }} is above %v.", opts.LatencyThreshold))
+
+	if opts.SaturationThreshold > 0 {
+		saturationThreshold := strconv.FormatFloat(opts.SaturationThreshold, 'g', 6, 64)
+		writeAlert("HTTPSaturated",
+			fmt.Sprintf("%s > %s", p.fqName("concurrent_requests"), saturationThreshold),
+			fmt.Sprintf("More than %s requests are served concurrently by {{ $labels.instance }}.", saturationThreshold))
+	}
+
+	return b.Bytes(), nil
+}
+
+// fqName returns the fully-qualified name of the metric with the default
+// name as registered.
+func (p *Prometheus) fqName(name string) string {
+	return prometheus.BuildFQName(p.namespace, p.subsystem, p.name(name))
+}
+
+func errorRatio(metric, by, window string) string {
+	return fmt.Sprintf(`sum by (%[2]s) (rate(%[1]s{code=~"5.."}[%[3]s])) / sum by (%[2]s) (rate(%[1]s[%[3]s]))`,
+		metric, by, window)
+}
+
+// quantileName turns 0.99 into p99 and 0.5 into p50.
+func quantileName(q float64) string {
+	digits := strings.TrimPrefix(strconv.FormatFloat(q, 'f', -1, 64), "0.")
+	if len(digits) == 1 {
+		digits += "0"
+	}
+	return "p" + digits
+}
+
+// quoteRule renders s as a single-quoted YAML scalar.
+func quoteRule(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
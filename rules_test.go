@@ -0,0 +1,113 @@
+package fasthttpprometheus
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the golden file testdata/name.golden, or
+// writes it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s:\n%s", name, path, got)
+	}
+}
+
+func TestRulesGolden(t *testing.T) {
+	custom := []func(*Prometheus){
+		Namespace("shop"),
+		Subsystem("api"),
+		ConstLabels(prometheus.Labels{"team": "checkout", "region": "eu"}),
+		Buckets([]float64{5, 10, 50, 100, 500, 1000}),
+		DurationUnit(Milliseconds),
+	}
+
+	tests := []struct {
+		name     string
+		options  []func(*Prometheus)
+		generate bool
+		opts     RuleOpts
+	}{
+		{name: "rules_default"},
+		{name: "generate_rules_default", generate: true},
+		{
+			name:    "rules_custom",
+			options: custom,
+			opts: RuleOpts{
+				GroupName:    "shop-api",
+				Window:       "1m",
+				Quantiles:    []float64{.75, .999},
+				SLOObjective: .999,
+			},
+		},
+		{
+			name:     "generate_rules_custom",
+			options:  custom,
+			generate: true,
+			opts: RuleOpts{
+				GroupName:           "shop-api",
+				Window:              "1m",
+				Quantiles:           []float64{.75, .999},
+				SLOObjective:        .999,
+				ErrorRatioThreshold: .01,
+				LatencyThreshold:    250 * time.Millisecond,
+				SaturationThreshold: 512,
+				For:                 "15m",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]func(*Prometheus){Registry(prometheus.NewRegistry())}, tt.options...)
+			p, err := NewPrometheusWithError(options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			render := p.PrometheusRules
+			if tt.generate {
+				render = p.GenerateRules
+			}
+			got, err := render(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rules struct {
+				Groups []struct {
+					Name  string
+					Rules []map[string]interface{}
+				}
+			}
+			if err := yaml.UnmarshalStrict(got, &rules); err != nil {
+				t.Fatalf("invalid YAML: %v", err)
+			}
+
+			checkGolden(t, tt.name, got)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleSeeds seeds the states of samplers, each drawn from it with a
+// distinct value.
+var sampleSeeds = uint64(time.Now().UnixNano())
+
+// samplers holds xorshift64* states. sync.Pool keeps them per P, so sampled
+// neither takes the lock of the global math/rand source nor shares a cache
+// line between the requests served in parallel.
+var samplers = sync.Pool{
+	New: func() interface{} {
+		x := atomic.AddUint64(&sampleSeeds, 0x9e3779b97f4a7c15)
+		x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+		x = (x ^ x>>27) * 0x94d049bb133111eb
+		x ^= x >> 31
+		if x == 0 {
+			x = 1
+		}
+		return &x
+	},
+}
+
+// SampleRate is an option which only records the given fraction of the requests, chosen at random, in the duration,
+// size, time to first byte and body read histograms and summaries, while requests_total and the other counters still
+// count all of them. The counts of the sampled histograms are then about rate times the number of requests
+func SampleRate(rate float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if rate <= 0 || rate > 1 {
+			p.optionErrs = append(p.optionErrs, fmt.Errorf("fasthttpprometheus: SampleRate %v is not in (0, 1]", rate))
+			return
+		}
+		p.sampleRate = rate
+		p.sampleThreshold = uint64(rate * math.MaxUint64)
+	}
+}
+
+// sampled reports whether the observations of a request are recorded in the
+// histograms and summaries.
+func (p *Prometheus) sampled() bool {
+	if p.sampleRate == 0 || p.sampleRate == 1 {
+		return true
+	}
+
+	s := samplers.Get().(*uint64)
+	x := *s
+	x ^= x >> 12
+	x ^= x << 25
+	x ^= x >> 27
+	*s = x
+	samplers.Put(s)
+
+	return x*0x2545f4914f6cdd1d < p.sampleThreshold
+}
@@ -0,0 +1,33 @@
+package fasthttpprometheus
+
+import "strings"
+
+// MountLabel is an option which adds a mount label to the request counter and duration histogram, set by the children returned from Scoped
+func MountLabel() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.mountLabel = true
+	}
+}
+
+// Scoped returns a child instrumenting a sub-router mounted under mount. The
+// child shares the collectors, registry and configuration of p, but records
+// mount as the mount label value and does not register the metrics handler
+// on the routers it wraps, so a single metrics endpoint is served by the root.
+//
+// Scoping a child again appends to its mount, so
+// p.Scoped("/api").Scoped("/v1") records the mount "/api/v1", as does
+// p.Scoped("/api/").Scoped("/v1"). The endpoint label keeps the full request
+// path, including the mount prefix.
+//
+// Scoped panics unless p was created with the MountLabel option.
+func (p *Prometheus) Scoped(mount string) *Prometheus {
+	if !p.mountLabel {
+		panic("fasthttpprometheus: Scoped requires the MountLabel option")
+	}
+
+	child := *p
+	child.mount = strings.TrimSuffix(p.mount, "/") + mount
+	child.scoped = true
+
+	return &child
+}
@@ -0,0 +1,52 @@
+package fasthttpprometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+)
+
+func TestScopedNested(t *testing.T) {
+	p := NewPrometheus(Registry(prometheus.NewRegistry()), MountLabel())
+	noop := func(*fasthttp.RequestCtx) {}
+
+	tests := []struct {
+		name  string
+		child *Prometheus
+		path  string
+		mount string
+	}{
+		{"root", p, "/health", ""},
+		{"scoped", p.Scoped("/api"), "/api/users", "/api"},
+		{"nested", p.Scoped("/api").Scoped("/v1"), "/api/v1/users", "/api/v1"},
+		{"nested after trailing slash", p.Scoped("/api/").Scoped("/v2"), "/api/v2/users", "/api/v2"},
+		{"nested twice", p.Scoped("/api").Scoped("/v1").Scoped("/admin"), "/api/v1/admin/users", "/api/v1/admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serve(tt.child.WrapHandlerFunc(noop), fasthttp.MethodGet, tt.path)
+
+			if got := testutil.ToFloat64(p.reqCnt.WithLabelValues("200", fasthttp.MethodGet, tt.path, tt.mount)); got != 1 {
+				t.Errorf("requests_total{endpoint=%q, mount=%q} = %v, want 1", tt.path, tt.mount, got)
+			}
+		})
+	}
+
+	if n := testutil.CollectAndCount(p.reqCnt); n != len(tests) {
+		t.Errorf("requests_total has %d series, want %d", n, len(tests))
+	}
+}
+
+func TestScopedDoesNotServeMetrics(t *testing.T) {
+	p := NewPrometheus(Registry(prometheus.NewRegistry()), MountLabel())
+	served := false
+	h := p.Scoped("/api").Scoped("/v1").WrapHandlerFunc(func(*fasthttp.RequestCtx) { served = true })
+
+	serve(h, fasthttp.MethodGet, p.MetricsPath)
+	if !served {
+		t.Errorf("the nested child served %s instead of the wrapped handler", p.MetricsPath)
+	}
+}
@@ -0,0 +1,171 @@
+package fasthttpprometheus
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// seriesTracker keeps track of the label value combinations observed on a
+// labeled collector, so the number of child series can be reported without
+// gathering the collector at scrape time.
+type seriesTracker struct {
+	mu    sync.RWMutex
+	seen  map[uint64][][]string // hashLabelValues -> label values
+	count int64
+}
+
+func newSeriesTracker() *seriesTracker {
+	return &seriesTracker{seen: map[uint64][][]string{}}
+}
+
+func (t *seriesTracker) track(lvs ...string) {
+	h := hashLabelValues(lvs)
+	t.mu.RLock()
+	i := indexLabelValues(t.seen[h], lvs)
+	t.mu.RUnlock()
+	if i >= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	if indexLabelValues(t.seen[h], lvs) < 0 {
+		t.seen[h] = append(t.seen[h], append([]string(nil), lvs...))
+		atomic.AddInt64(&t.count, 1)
+	}
+	t.mu.Unlock()
+}
+
+func (t *seriesTracker) forget(lvs ...string) {
+	h := hashLabelValues(lvs)
+	t.mu.Lock()
+	if i := indexLabelValues(t.seen[h], lvs); i >= 0 {
+		t.remove(h, i)
+	}
+	t.mu.Unlock()
+}
+
+// forgetValue forgets the series whose label value at index is value.
+func (t *seriesTracker) forgetValue(index int, value string) {
+	t.mu.Lock()
+	for h, bucket := range t.seen {
+		for i := len(bucket) - 1; i >= 0; i-- {
+			if index < len(bucket[i]) && bucket[i][index] == value {
+				t.remove(h, i)
+			}
+		}
+	}
+	t.mu.Unlock()
+}
+
+// remove removes the label values at i of the bucket of hash h. t.mu must be
+// locked.
+func (t *seriesTracker) remove(h uint64, i int) {
+	bucket := t.seen[h]
+	bucket[i] = bucket[len(bucket)-1]
+	if bucket = bucket[:len(bucket)-1]; len(bucket) == 0 {
+		delete(t.seen, h)
+	} else {
+		t.seen[h] = bucket
+	}
+	atomic.AddInt64(&t.count, -1)
+}
+
+func (t *seriesTracker) reset() {
+	t.mu.Lock()
+	t.seen = map[uint64][][]string{}
+	atomic.StoreInt64(&t.count, 0)
+	t.mu.Unlock()
+}
+
+func (t *seriesTracker) value() float64 {
+	return float64(atomic.LoadInt64(&t.count))
+}
+
+// SeriesCount is an option which enables the metric_series gauge reporting the number of child series of every labeled collector
+func SeriesCount() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.series = map[string]*seriesTracker{}
+	}
+}
+
+// trackSeries records lvs as a child series of the collector named name.
+// It is a no-op unless the SeriesCount option is set.
+func (p *Prometheus) trackSeries(name string, lvs ...string) {
+	if t, ok := p.series[name]; ok {
+		t.track(lvs...)
+	}
+}
+
+// forgetSeries records that the child series lvs of the collector named name
+// was deleted.
+func (p *Prometheus) forgetSeries(name string, lvs ...string) {
+	if t, ok := p.series[name]; ok {
+		t.forget(lvs...)
+	}
+}
+
+// seriesCollectors creates a tracker and the matching metric_series gauge for
+// each of the given labeled collector names.
+func (p *Prometheus) seriesCollectors(names ...string) []prometheus.Collector {
+	if p.series == nil {
+		return nil
+	}
+
+	collectors := make([]prometheus.Collector, 0, len(names))
+	for _, name := range names {
+		t := newSeriesTracker()
+		p.series[name] = t
+
+		collectors = append(collectors, prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Namespace:   p.namespace,
+				Subsystem:   p.subsystem,
+				Name:        p.name("metric_series"),
+				Help:        "The number of child series of a labeled collector.",
+				ConstLabels: p.constLabelsWith(prometheus.Labels{"metric": p.name(name)}),
+			},
+			t.value,
+		))
+	}
+
+	return collectors
+}
+
+// hashLabelValues returns the FNV-1a hash of lvs, each followed by a 0xff
+// byte. Unlike joining them into a map key, it does not allocate.
+func hashLabelValues(lvs []string) uint64 {
+	h := uint64(14695981039346656037)
+	for _, lv := range lvs {
+		for i := 0; i < len(lv); i++ {
+			h ^= uint64(lv[i])
+			h *= 1099511628211
+		}
+		h ^= 0xff
+		h *= 1099511628211
+	}
+	return h
+}
+
+// indexLabelValues returns the index of lvs in bucket, or -1.
+func indexLabelValues(bucket [][]string, lvs []string) int {
+	for i, b := range bucket {
+		if equalLabelValues(b, lvs) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalLabelValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
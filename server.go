@@ -0,0 +1,106 @@
+package fasthttpprometheus
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// metricsServers keeps track of the dedicated metrics servers started by a
+// Prometheus, for ShutdownMetrics.
+type metricsServers struct {
+	mu      sync.Mutex
+	servers []*fasthttp.Server
+}
+
+// ListenAndServeMetrics serves the metrics on the metrics paths of a dedicated
+// server listening on the TCP address addr, so they need not be exposed by
+// the application's own server. It blocks until the server fails or is shut
+// down with ShutdownMetrics. With the MetricsTLS option, it serves over TLS.
+func (p *Prometheus) ListenAndServeMetrics(addr string) error {
+	if p.metricsTLS != nil {
+		return p.listenAndServeMetricsTLS(addr)
+	}
+
+	return p.newMetricsServer().ListenAndServe(addr)
+}
+
+// ListenAndServeMetricsUNIX is the counterpart of ListenAndServeMetrics
+// listening on the unix domain socket addr instead, created with the file
+// permissions mode. An existing file at addr is removed first.
+func (p *Prometheus) ListenAndServeMetricsUNIX(addr string, mode os.FileMode) error {
+	return p.newMetricsServer().ListenAndServeUNIX(addr, mode)
+}
+
+// ShutdownMetrics gracefully shuts down the servers started with
+// ListenAndServeMetrics and ListenAndServeMetricsUNIX, waiting for the scrapes in flight to complete.
+func (p *Prometheus) ShutdownMetrics() error {
+	p.servers.mu.Lock()
+	servers := p.servers.servers
+	p.servers.servers = nil
+	p.servers.mu.Unlock()
+
+	var firstErr error
+	for _, s := range servers {
+		if err := s.Shutdown(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Shutdown prepares p for the exit of the process: it stops the background
+// work of the SeriesTTL, PushGateway, Multiprocess and AsyncObservations options, recording the queued observations,
+// and performs a final push and write, so the metrics collected since the last push are not lost, then gracefully
+// shuts down the metrics servers. It gives up waiting when ctx is done.
+func (p *Prometheus) Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+
+	go func() {
+		p.stopExpiry()
+		p.stopAsync()
+		p.stopMultiprocess()
+		p.StopPush()
+		err := p.pushOnce(ctx)
+		if serr := p.ShutdownMetrics(); err == nil {
+			err = serr
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Prometheus) newMetricsServer() *fasthttp.Server {
+	s := &fasthttp.Server{
+		Handler: p.metricsServerHandler(),
+	}
+
+	p.servers.mu.Lock()
+	p.servers.servers = append(p.servers.servers, s)
+	p.servers.mu.Unlock()
+
+	return s
+}
+
+// metricsServerHandler serves the metrics on the metrics paths and nothing else.
+func (p *Prometheus) metricsServerHandler() fasthttp.RequestHandler {
+	metrics := p.metricsHandler()
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if !p.isMetricsPath(string(ctx.Path())) {
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusNotFound), fasthttp.StatusNotFound)
+			return
+		}
+
+		metrics(ctx)
+	}
+}
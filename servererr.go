@@ -0,0 +1,76 @@
+package fasthttpprometheus
+
+import (
+	"errors"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// serverErrorType returns the type label of server_errors_total for an error
+// passed by fasthttp.Server to its ErrorHandler.
+func serverErrorType(err error) string {
+	var smallBuffer *fasthttp.ErrSmallBuffer
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, fasthttp.ErrBodyTooLarge):
+		return "body_too_large"
+	case errors.As(err, &smallBuffer):
+		return "header_too_large"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "read_timeout"
+	case errors.Is(err, fasthttp.ErrGetOnly):
+		return "get_only"
+	case errors.Is(err, fasthttp.ErrBadTrailer):
+		return "bad_trailer"
+	}
+	return "malformed_request"
+}
+
+// ServerErrorHandler returns a handler to be set as fasthttp.Server.ErrorHandler
+// which counts the errors fasthttp.Server encounters reading requests in
+// server_errors_total by type before calling next. A nil next answers like
+// the default fasthttp.Server error handler.
+func (p *Prometheus) ServerErrorHandler(next func(*fasthttp.RequestCtx, error)) func(*fasthttp.RequestCtx, error) {
+	if next == nil {
+		next = defaultServerErrorHandler
+	}
+
+	return func(ctx *fasthttp.RequestCtx, err error) {
+		p.serverErrs.WithLabelValues(serverErrorType(err)).Inc()
+		next(ctx, err)
+	}
+}
+
+// defaultServerErrorHandler mirrors the error handler fasthttp.Server uses
+// when its ErrorHandler is not set.
+func defaultServerErrorHandler(ctx *fasthttp.RequestCtx, err error) {
+	var smallBuffer *fasthttp.ErrSmallBuffer
+	var opErr *net.OpError
+
+	switch {
+	case errors.As(err, &smallBuffer):
+		ctx.Error("Too big request header", fasthttp.StatusRequestHeaderFieldsTooLarge)
+	case errors.As(err, &opErr) && opErr.Timeout():
+		ctx.Error("Request timeout", fasthttp.StatusRequestTimeout)
+	default:
+		ctx.Error("Error when parsing request", fasthttp.StatusBadRequest)
+	}
+}
+
+func (p *Prometheus) serverErrorCollector() prometheus.Collector {
+	p.serverErrs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("server_errors_total"),
+			Help:        "The errors of the fasthttp server reading HTTP requests by type.",
+		},
+		[]string{"type"},
+	)
+
+	return p.serverErrs
+}
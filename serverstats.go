@@ -0,0 +1,42 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// CollectServer registers gauges of the open connections, current concurrency and concurrency limit of s, collected
+// on scrape. With several servers, each must have a distinct Name, recorded as the server label. The connections
+// rejected as the concurrency limit is reached are not counted: fasthttp v1.40 does not expose them, and only logs
+// them at most once a minute
+func (p *Prometheus) CollectServer(s *fasthttp.Server) {
+	var constLabels prometheus.Labels
+	if s.Name != "" {
+		constLabels = prometheus.Labels{"server": s.Name}
+	}
+
+	opts := func(name, help string) prometheus.GaugeOpts {
+		return prometheus.GaugeOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabelsWith(constLabels),
+			Name:        p.name(name),
+			Help:        help,
+		}
+	}
+
+	p.registerCollectors(
+		prometheus.NewGaugeFunc(opts("server_open_connections", "Number of open connections of the fasthttp server."), func() float64 {
+			return float64(s.GetOpenConnectionsCount())
+		}),
+		prometheus.NewGaugeFunc(opts("server_concurrency", "Number of connections being served by the fasthttp server."), func() float64 {
+			return float64(s.GetCurrentConcurrency())
+		}),
+		prometheus.NewGaugeFunc(opts("server_concurrency_limit", "The maximum number of connections served concurrently by the fasthttp server."), func() float64 {
+			if s.Concurrency <= 0 {
+				return fasthttp.DefaultConcurrency
+			}
+			return float64(s.Concurrency)
+		}),
+	)
+}
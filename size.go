@@ -0,0 +1,108 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultSizeBuckets are the byte buckets of the size histograms, from 64B
+// to 1MiB.
+var defaultSizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+// RequestSizeHistogram is an option which replaces the unlabeled request size summary with a request_size_bytes
+// histogram partitioned by method and endpoint, which can be aggregated across instances. Without buckets, the
+// buckets set with ExponentialSizeBuckets or LinearSizeBuckets are used, by default byte buckets from 64B to 1MiB
+func RequestSizeHistogram(buckets ...float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.reqSizeBuckets = append([]float64{}, buckets...)
+	}
+}
+
+// observeRequestSize observes the request size in stats.
+func (p *Prometheus) observeRequestSize(stats RequestStats) {
+	if p.reqSizeHist == nil {
+		p.reqSize.WithLabelValues().Observe(float64(stats.RequestSize))
+		return
+	}
+
+	p.reqSizeHist.WithLabelValues(stats.Method, stats.Endpoint).Observe(float64(stats.RequestSize))
+	p.trackSeries("request_size_bytes", stats.Method, stats.Endpoint)
+}
+
+// ResponseSizeHistogram is an option which replaces the response size summary with a response_size_bytes histogram
+// partitioned by code, method and endpoint, along with content_type with the ContentTypeLabel option. Without buckets,
+// the same buckets as with RequestSizeHistogram are used
+func ResponseSizeHistogram(buckets ...float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.respSizeBuckets = append([]float64{}, buckets...)
+	}
+}
+
+// sizeBucketsOr returns buckets, or the size buckets set with
+// ExponentialSizeBuckets or LinearSizeBuckets if there are none.
+func (p *Prometheus) sizeBucketsOr(buckets []float64) []float64 {
+	if len(buckets) > 0 {
+		return buckets
+	}
+	if p.sizeBuckets != nil {
+		return p.sizeBuckets
+	}
+	return defaultSizeBuckets
+}
+
+// observeResponseSize observes the response size in stats, whose code label
+// value is status.
+func (p *Prometheus) observeResponseSize(stats RequestStats, status string) {
+	size := float64(stats.ResponseSize)
+
+	switch {
+	case p.respSizeHist != nil:
+		lvs := []string{status, stats.Method, stats.Endpoint}
+		if p.contentTypeLabel {
+			lvs = append(lvs, stats.ContentType)
+		}
+		p.respSizeHist.WithLabelValues(lvs...).Observe(size)
+		p.trackSeries("response_size_bytes", lvs...)
+	case p.contentTypeLabel:
+		p.respSize.WithLabelValues(stats.ContentType).Observe(size)
+		p.trackSeries("response_size_bytes", stats.ContentType)
+	default:
+		p.respSize.WithLabelValues().Observe(size)
+	}
+}
+
+func (p *Prometheus) requestSizeCollector() prometheus.Collector {
+	p.reqSizeHist = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("request_size_bytes"),
+			Help:        "The HTTP request sizes in bytes.",
+			Buckets:     p.sizeBucketsOr(p.reqSizeBuckets),
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	return p.reqSizeHist
+}
+
+func (p *Prometheus) responseSizeCollector() prometheus.Collector {
+	labels := []string{"code", "method", "endpoint"}
+	if p.contentTypeLabel {
+		labels = append(labels, "content_type")
+	}
+
+	p.respSizeHist = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("response_size_bytes"),
+			Help:        "The HTTP response sizes in bytes.",
+			Buckets:     p.sizeBucketsOr(p.respSizeBuckets),
+		},
+		labels,
+	)
+
+	return p.respSizeHist
+}
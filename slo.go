@@ -0,0 +1,87 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type latencySLO struct {
+	target    time.Duration
+	endpoints map[string]time.Duration
+
+	within *prometheus.CounterVec
+}
+
+// LatencySLO is an option which counts the requests served within their latency target in requests_within_slo_total
+// by endpoint, whose ratio to requests_total is the latency compliance. endpoints sets the targets of specific
+// endpoints, by endpoint label value, the others having target, or no target if it is zero
+func LatencySLO(target time.Duration, endpoints map[string]time.Duration) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.latencySLO = &latencySLO{target: target, endpoints: endpoints}
+	}
+}
+
+// targetOf returns the latency target of endpoint, or zero if it has none.
+func (s *latencySLO) targetOf(endpoint string) time.Duration {
+	if target, ok := s.endpoints[endpoint]; ok {
+		return target
+	}
+	return s.target
+}
+
+// observeSLO counts the request of stats if it was served within its
+// latency target, with the LatencySLO option.
+func (p *Prometheus) observeSLO(stats RequestStats) {
+	s := p.latencySLO
+	if s == nil {
+		return
+	}
+
+	target := s.targetOf(stats.Endpoint)
+	if target <= 0 {
+		return
+	}
+
+	// The series is created for every endpoint with a target, so the
+	// compliance ratio is defined even when no request meets the target.
+	c := s.within.WithLabelValues(stats.Endpoint)
+	p.trackSeries("requests_within_slo_total", stats.Endpoint)
+	if stats.Duration <= target {
+		c.Inc()
+	}
+}
+
+// validate checks the latency targets.
+func (s *latencySLO) validate() error {
+	if s == nil {
+		return nil
+	}
+
+	if s.target < 0 {
+		return fmt.Errorf("fasthttpprometheus: LatencySLO target %v is negative", s.target)
+	}
+	for endpoint, target := range s.endpoints {
+		if target <= 0 {
+			return fmt.Errorf("fasthttpprometheus: LatencySLO target %v of endpoint %q is not positive", target, endpoint)
+		}
+	}
+
+	return nil
+}
+
+func (p *Prometheus) sloCollector() prometheus.Collector {
+	p.latencySLO.within = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("requests_within_slo_total"),
+			Help:        "The HTTP requests served within the latency target of their endpoint.",
+		},
+		[]string{"endpoint"},
+	)
+
+	return p.latencySLO.within
+}
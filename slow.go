@@ -0,0 +1,55 @@
+package fasthttpprometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+type slowRequests struct {
+	threshold time.Duration
+	fn        func(*fasthttp.RequestCtx, time.Duration)
+
+	counter *prometheus.CounterVec
+}
+
+// SlowRequestThreshold is an option which counts the requests taking longer than threshold in slow_requests_total by
+// endpoint, and calls fn, if not nil, with each of them, e.g. to log their details. ctx must not be retained after
+// fn returns
+func SlowRequestThreshold(threshold time.Duration, fn func(ctx *fasthttp.RequestCtx, elapsed time.Duration)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.slow = &slowRequests{threshold: threshold, fn: fn}
+	}
+}
+
+// checkSlow counts the request in ctx if it is slow, with the
+// SlowRequestThreshold option.
+func (p *Prometheus) checkSlow(ctx *fasthttp.RequestCtx, stats RequestStats) {
+	s := p.slow
+	if s == nil || stats.Duration <= s.threshold {
+		return
+	}
+
+	s.counter.WithLabelValues(stats.Endpoint).Inc()
+	p.trackSeries("slow_requests_total", stats.Endpoint)
+
+	if s.fn != nil {
+		s.fn(ctx, stats.Duration)
+	}
+}
+
+func (p *Prometheus) slowCollector() prometheus.Collector {
+	p.slow.counter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("slow_requests_total"),
+			Help:        "The HTTP requests taking longer than the slow request threshold.",
+		},
+		[]string{"endpoint"},
+	)
+
+	return p.slow.counter
+}
@@ -0,0 +1,146 @@
+package fasthttpprometheus
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/valyala/fasthttp"
+)
+
+type snapshotFamily struct {
+	Name    string           `json:"name"`
+	Help    string           `json:"help"`
+	Type    string           `json:"type"`
+	Metrics []snapshotMetric `json:"metrics"`
+}
+
+type snapshotMetric struct {
+	Labels    map[string]string  `json:"labels,omitempty"`
+	Value     *float64           `json:"value,omitempty"`
+	Count     *uint64            `json:"count,omitempty"`
+	Sum       *float64           `json:"sum,omitempty"`
+	Buckets   map[string]uint64  `json:"buckets,omitempty"`
+	Quantiles map[string]float64 `json:"quantiles,omitempty"`
+}
+
+// MetricsJSONPath is an option which also serves the SnapshotJSON of the middleware's collectors on path
+func MetricsJSONPath(path string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.jsonPath = path
+	}
+}
+
+// SnapshotJSON returns the current values of the middleware's own collectors
+// as JSON, a list of metric families with their labeled metrics.
+func (p *Prometheus) SnapshotJSON() ([]byte, error) {
+	mfs, err := p.own.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	families := make([]snapshotFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		family := snapshotFamily{
+			Name:    mf.GetName(),
+			Help:    mf.GetHelp(),
+			Type:    jsonType(mf.GetType()),
+			Metrics: make([]snapshotMetric, 0, len(mf.GetMetric())),
+		}
+		for _, m := range mf.GetMetric() {
+			family.Metrics = append(family.Metrics, snapshotOf(m))
+		}
+		families = append(families, family)
+	}
+
+	return json.Marshal(families)
+}
+
+func jsonType(t dto.MetricType) string {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return "counter"
+	case dto.MetricType_GAUGE:
+		return "gauge"
+	case dto.MetricType_SUMMARY:
+		return "summary"
+	case dto.MetricType_HISTOGRAM:
+		return "histogram"
+	}
+	return "untyped"
+}
+
+func snapshotOf(m *dto.Metric) snapshotMetric {
+	var s snapshotMetric
+
+	if len(m.GetLabel()) > 0 {
+		s.Labels = make(map[string]string, len(m.GetLabel()))
+		for _, l := range m.GetLabel() {
+			s.Labels[l.GetName()] = l.GetValue()
+		}
+	}
+
+	switch {
+	case m.Counter != nil:
+		s.Value = m.Counter.Value
+	case m.Gauge != nil:
+		s.Value = m.Gauge.Value
+	case m.Untyped != nil:
+		s.Value = m.Untyped.Value
+	case m.Summary != nil:
+		s.Count = m.Summary.SampleCount
+		s.Sum = m.Summary.SampleSum
+		if len(m.Summary.Quantile) > 0 {
+			s.Quantiles = make(map[string]float64, len(m.Summary.Quantile))
+			for _, q := range m.Summary.Quantile {
+				s.Quantiles[strconv.FormatFloat(q.GetQuantile(), 'f', -1, 64)] = q.GetValue()
+			}
+		}
+	case m.Histogram != nil:
+		s.Count = m.Histogram.SampleCount
+		s.Sum = m.Histogram.SampleSum
+		s.Buckets = make(map[string]uint64, len(m.Histogram.Bucket))
+		for _, b := range m.Histogram.Bucket {
+			s.Buckets[strconv.FormatFloat(b.GetUpperBound(), 'f', -1, 64)] = b.GetCumulativeCount()
+		}
+	}
+
+	return s
+}
+
+func (p *Prometheus) snapshotJSONHandler(ctx *fasthttp.RequestCtx) {
+	b, err := p.SnapshotJSON()
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetBody(b)
+}
+
+// WriteTo gathers the configured registry and writes it to w in the
+// Prometheus text exposition format, e.g. to dump the metrics to logs. It
+// returns the number of bytes written along with the error rather than only
+// the error: a method named WriteTo is expected to implement io.WriterTo,
+// which go vet enforces, and doing so lets the Prometheus be passed to
+// functions like io.Copy.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	mfs, err := p.gatherer().Gather()
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	for _, mf := range mfs {
+		n, err := expfmt.MetricFamilyToText(w, mf)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
@@ -0,0 +1,43 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// WithStandardCollectors is an option which registers the Go runtime and process collectors on the registry set with
+// Registry or Registerer, as the default registry already has them
+func WithStandardCollectors() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.standardCollectors = true
+	}
+}
+
+// registerStandardCollectors registers the collectors of
+// WithStandardCollectors, returning an unregister func to roll back.
+func (p *Prometheus) registerStandardCollectors() (func(), error) {
+	if !p.standardCollectors || p.registerer == nil {
+		return func() {}, nil
+	}
+
+	standard := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	}
+	unregister := func() {
+		for _, c := range standard {
+			p.registerer.Unregister(c)
+		}
+	}
+
+	for _, c := range standard {
+		if err := p.registerer.Register(c); err != nil {
+			unregister()
+			return nil, fmt.Errorf("fasthttpprometheus: registering standard collectors: %w", err)
+		}
+	}
+
+	return unregister, nil
+}
@@ -0,0 +1,111 @@
+package fasthttpprometheus
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// streamKey is the user value key of the countingStream set by
+// SetBodyStream.
+const streamKey = "fasthttpprometheus.stream"
+
+// countingStream is a response body stream counting the bytes read from it
+// by the server, calling done with the count once it is closed.
+type countingStream struct {
+	r io.Reader
+	n int
+
+	// firstRead is the time the server started reading the stream, after
+	// sending the response headers.
+	firstRead time.Time
+
+	mu     sync.Mutex
+	closed bool
+	done   func(n int)
+}
+
+func (s *countingStream) Read(b []byte) (int, error) {
+	if s.firstRead.IsZero() {
+		s.firstRead = time.Now()
+	}
+	n, err := s.r.Read(b)
+	s.n += n
+	return n, err
+}
+
+func (s *countingStream) Close() error {
+	var err error
+	if c, ok := s.r.(io.Closer); ok {
+		err = c.Close()
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	done := s.done
+	s.mu.Unlock()
+
+	if done != nil {
+		done(s.n)
+	}
+	return err
+}
+
+// onDone sets the function called with the count of bytes streamed once the
+// stream is closed, calling it right away if it already is.
+func (s *countingStream) onDone(done func(n int)) {
+	s.mu.Lock()
+	closed := s.closed
+	if !closed {
+		s.done = done
+	}
+	s.mu.Unlock()
+
+	if closed {
+		done(s.n)
+	}
+}
+
+// streamRef holds the countingStream of a request as a user value, as the
+// user values implementing io.Closer are closed with the request context.
+type streamRef struct {
+	s *countingStream
+}
+
+// SetBodyStream is the counterpart of ctx.SetBodyStream measuring the size of the streamed response body, which is
+// otherwise only known from the Content-Length header. The request is then observed once the body has been sent
+func (p *Prometheus) SetBodyStream(ctx *fasthttp.RequestCtx, bodyStream io.Reader, bodySize int) {
+	s := &countingStream{r: bodyStream}
+	ctx.SetUserValue(streamKey, streamRef{s})
+	ctx.SetBodyStream(s, bodySize)
+}
+
+// SetBodyStreamWriter is the counterpart of ctx.SetBodyStreamWriter measuring the size of the streamed response body,
+// as SetBodyStream does
+func (p *Prometheus) SetBodyStreamWriter(ctx *fasthttp.RequestCtx, sw fasthttp.StreamWriter) {
+	p.SetBodyStream(ctx, fasthttp.NewStreamReader(sw), -1)
+}
+
+// bodyStream returns the countingStream set by SetBodyStream for the response
+// in ctx, or nil if its body is not streamed through one.
+func bodyStream(ctx *fasthttp.RequestCtx) *countingStream {
+	if !ctx.Response.IsBodyStream() {
+		return nil
+	}
+	ref, _ := ctx.UserValue(streamKey).(streamRef)
+	return ref.s
+}
+
+// responseSize returns the size of the response body in ctx, which for
+// streamed bodies is the Content-Length, if known.
+func responseSize(ctx *fasthttp.RequestCtx) int {
+	if ctx.Response.IsBodyStream() {
+		if n := ctx.Response.Header.ContentLength(); n >= 0 {
+			return n
+		}
+		return 0
+	}
+	return len(ctx.Response.Body())
+}
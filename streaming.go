@@ -0,0 +1,49 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StreamingEndpoints is an option which marks endpoints, by endpoint label value, as serving long-lived responses
+// such as server-sent events or long polls. Their request duration is the time to first byte rather than the time
+// to respond, and the responses streamed with SetBodyStream or SetBodyStreamWriter are tracked in the open_streams
+// gauge until fully sent
+func StreamingEndpoints(endpoints ...string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if p.streaming == nil {
+			p.streaming = map[string]bool{}
+		}
+		for _, e := range endpoints {
+			p.streaming[e] = true
+		}
+	}
+}
+
+// openStream increments the open_streams gauge of endpoint, if it is a
+// streaming endpoint, and returns the function decrementing it.
+func (p *Prometheus) openStream(endpoint string) func() {
+	if !p.streaming[endpoint] {
+		return func() {}
+	}
+
+	g := p.openStreams.WithLabelValues(endpoint)
+	p.trackSeries("open_streams", endpoint)
+
+	g.Inc()
+	return g.Dec
+}
+
+func (p *Prometheus) openStreamsCollector() prometheus.Collector {
+	p.openStreams = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("open_streams"),
+			Help:        "Number of HTTP responses of the streaming endpoints being streamed.",
+		},
+		[]string{"endpoint"},
+	)
+
+	return p.openStreams
+}
@@ -0,0 +1,64 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type summaryConfig struct {
+	objectives map[float64]float64
+	maxAge     time.Duration
+	ageBuckets uint32
+}
+
+// SummaryObjectives is an option which sets the quantile objectives of the request and response size summaries,
+// e.g. map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}, which otherwise expose no quantiles
+func SummaryObjectives(objectives map[float64]float64) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.summary.objectives = objectives
+	}
+}
+
+// SummaryWindow is an option which sets the duration for which the observations of the size summaries are kept in
+// the quantiles, and the number of buckets of this sliding window
+func SummaryWindow(maxAge time.Duration, ageBuckets uint32) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.summary.maxAge = maxAge
+		p.summary.ageBuckets = ageBuckets
+	}
+}
+
+// summaryOpts returns the options of the summary named name with help,
+// configured with SummaryObjectives and SummaryWindow.
+func (p *Prometheus) summaryOpts(name, help string) prometheus.SummaryOpts {
+	return prometheus.SummaryOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name(name),
+		Help:        help,
+		Objectives:  p.summary.objectives,
+		MaxAge:      p.summary.maxAge,
+		AgeBuckets:  p.summary.ageBuckets,
+	}
+}
+
+// validate checks the summary configuration.
+func (s summaryConfig) validate() error {
+	for q, e := range s.objectives {
+		if q < 0 || q > 1 {
+			return fmt.Errorf("fasthttpprometheus: summary objective quantile %v is not between 0 and 1", q)
+		}
+		if e < 0 {
+			return fmt.Errorf("fasthttpprometheus: summary objective error %v of quantile %v is negative", e, q)
+		}
+	}
+
+	if s.maxAge < 0 {
+		return fmt.Errorf("fasthttpprometheus: SummaryWindow max age %v is negative", s.maxAge)
+	}
+
+	return nil
+}
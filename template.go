@@ -0,0 +1,153 @@
+package fasthttpprometheus
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/buaazp/fasthttprouter"
+	"github.com/valyala/fasthttp"
+)
+
+// templateSentinel replaces a path segment to find out which of several
+// identical segments a route parameter was matched against.
+const templateSentinel = "\x00"
+
+var lookupCtxPool sync.Pool
+
+type routeParam struct {
+	name, value string
+}
+
+// routerRouting gives access to the routes of a fasthttprouter.Router.
+type routerRouting struct {
+	r *fasthttprouter.Router
+}
+
+func (rt routerRouting) pattern(method, path string) string {
+	return routeTemplate(rt.r, method, path)
+}
+
+func (rt routerRouting) matched(ctx *fasthttp.RequestCtx) string {
+	return routeTemplate(rt.r, string(ctx.Method()), string(ctx.Path()))
+}
+
+func (rt routerRouting) redirect(ctx *fasthttp.RequestCtx) (kind, target string, ok bool) {
+	return routerRedirect(ctx, func(method, path string) (bool, bool) {
+		handler, tsr := rt.r.Lookup(method, path, nil)
+		return handler != nil, tsr
+	}, rt.r.RedirectTrailingSlash)
+}
+
+func (rt routerRouting) unmatched(ctx *fasthttp.RequestCtx) bool {
+	handler, _ := rt.r.Lookup(string(ctx.Method()), string(ctx.Path()), nil)
+	return handler == nil
+}
+
+// routeTemplate returns the pattern of the route in r matching method and
+// path. The router does not expose the matched pattern, so it is rebuilt by
+// substituting the route parameters found by a lookup back into the path.
+// Paths without a matching route are returned unchanged.
+func routeTemplate(r *fasthttprouter.Router, method, path string) string {
+	params, ok := lookupParams(r, method, path)
+	if !ok || len(params) == 0 {
+		return path
+	}
+
+	var b strings.Builder
+	rest, offset := path, 0
+
+	for _, param := range params {
+		// Catch-all parameters hold the remainder of the path, including
+		// its leading slash.
+		if strings.HasPrefix(param.value, "/") && strings.HasSuffix(rest, param.value) {
+			b.WriteString(rest[:len(rest)-len(param.value)])
+			b.WriteString("/*")
+			b.WriteString(param.name)
+			return b.String()
+		}
+
+		i := paramSegment(r, method, path, offset, param)
+		if i < 0 {
+			return path
+		}
+
+		b.WriteString(path[offset:i])
+		b.WriteString(":")
+		b.WriteString(param.name)
+
+		offset = i + len(param.value)
+		rest = path[offset:]
+	}
+
+	b.WriteString(rest)
+	return b.String()
+}
+
+// paramSegment returns the index in path, at or after offset, of the segment
+// param was matched against, or -1 if there is none.
+func paramSegment(r *fasthttprouter.Router, method, path string, offset int, param routeParam) int {
+	candidates := []int{}
+	for i := offset; i < len(path); {
+		j := strings.Index(path[i:], param.value)
+		if j < 0 {
+			break
+		}
+		j += i
+
+		end := j + len(param.value)
+		if j > 0 && path[j-1] == '/' && (end == len(path) || path[end] == '/') {
+			candidates = append(candidates, j)
+		}
+		i = j + 1
+	}
+
+	switch len(candidates) {
+	case 0:
+		return -1
+	case 1:
+		return candidates[0]
+	}
+
+	// A static segment equals the parameter value, so try each candidate.
+	for _, i := range candidates {
+		probe := path[:i] + templateSentinel + path[i+len(param.value):]
+		params, ok := lookupParams(r, method, probe)
+		if !ok {
+			continue
+		}
+		for _, pp := range params {
+			if pp.name == param.name && pp.value == templateSentinel {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// lookupParams looks up the route matching method and path in r and returns
+// its parameters in path order.
+func lookupParams(r *fasthttprouter.Router, method, path string) ([]routeParam, bool) {
+	ctx, _ := lookupCtxPool.Get().(*fasthttp.RequestCtx)
+	if ctx == nil {
+		ctx = new(fasthttp.RequestCtx)
+	}
+	defer func() {
+		ctx.ResetUserValues()
+		lookupCtxPool.Put(ctx)
+	}()
+
+	handler, _ := r.Lookup(method, path, ctx)
+	if handler == nil {
+		return nil, false
+	}
+
+	var params []routeParam
+	ctx.VisitUserValues(func(key []byte, value interface{}) {
+		if s, ok := value.(string); ok {
+			params = append(params, routeParam{name: string(key), value: s})
+		}
+	})
+
+	return params, true
+}
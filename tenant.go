@@ -0,0 +1,74 @@
+package fasthttpprometheus
+
+import (
+	"hash/fnv"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+)
+
+// defaultTenantBuckets is the number of hash buckets the tenants are recorded
+// as without TenantAllowlist and TenantHashBuckets, to bound the cardinality
+// of the tenant label.
+const defaultTenantBuckets = 32
+
+type tenant struct {
+	header  string
+	allowed map[string]bool
+	buckets uint32
+}
+
+// TenantHeader is an option which adds a tenant label to the request counter and duration histogram, read from the
+// named request header. Requests without the header are recorded with the tenant "unknown". Without TenantAllowlist,
+// the tenants are recorded as one of 32 hash buckets, or as many as set by TenantHashBuckets, so the clients cannot
+// create series at will
+func TenantHeader(name string, options ...func(*tenant)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		t := &tenant{header: name}
+		for _, option := range options {
+			option(t)
+		}
+		if t.allowed == nil && t.buckets == 0 {
+			t.buckets = defaultTenantBuckets
+		}
+		p.addExtraLabel("tenant", t.label)
+	}
+}
+
+// TenantAllowlist is a TenantHeader option which records the tenants not in the list, or not valid UTF-8, as "other"
+func TenantAllowlist(tenants ...string) func(*tenant) {
+	return func(t *tenant) {
+		t.allowed = make(map[string]bool, len(tenants))
+		for _, name := range tenants {
+			t.allowed[name] = true
+		}
+	}
+}
+
+// TenantHashBuckets is a TenantHeader option which records the tenants as one of n hash buckets, 0 to n-1
+func TenantHashBuckets(n int) func(*tenant) {
+	return func(t *tenant) {
+		if n > 0 {
+			t.buckets = uint32(n)
+		}
+	}
+}
+
+func (t *tenant) label(ctx *fasthttp.RequestCtx) string {
+	value := ctx.Request.Header.Peek(t.header)
+	if len(value) == 0 {
+		return "unknown"
+	}
+
+	if t.allowed != nil {
+		if !utf8.Valid(value) || !t.allowed[string(value)] {
+			return "other"
+		}
+		return string(value)
+	}
+
+	h := fnv.New32a()
+	h.Write(value)
+	return strconv.FormatUint(uint64(h.Sum32()%t.buckets), 10)
+}
@@ -0,0 +1,56 @@
+package fasthttpprometheus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+// MetricsTLS is an option which makes ListenAndServeMetrics serve over TLS with a copy of config, which must hold the server certificates
+func MetricsTLS(config *tls.Config, options ...func(*tls.Config)) func(*Prometheus) {
+	return func(p *Prometheus) {
+		cfg := config.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.MinVersion == 0 {
+			cfg.MinVersion = tls.VersionTLS12
+		}
+
+		for _, option := range options {
+			option(cfg)
+		}
+
+		p.metricsTLS = cfg
+	}
+}
+
+// ClientCAs is a MetricsTLS option which requires scrapes to present a client certificate signed by one of pool
+func ClientCAs(pool *x509.CertPool) func(*tls.Config) {
+	return func(cfg *tls.Config) {
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+}
+
+// MinTLSVersion is a MetricsTLS option which sets the minimum TLS version accepted, TLS 1.2 by default
+func MinTLSVersion(version uint16) func(*tls.Config) {
+	return func(cfg *tls.Config) {
+		cfg.MinVersion = version
+	}
+}
+
+// listenAndServeMetricsTLS serves the metrics over TLS on the TCP address addr.
+func (p *Prometheus) listenAndServeMetricsTLS(addr string) error {
+	if len(p.metricsTLS.Certificates) == 0 && p.metricsTLS.GetCertificate == nil {
+		return errors.New("fasthttpprometheus: MetricsTLS config has no certificates")
+	}
+
+	ln, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return err
+	}
+
+	return p.newMetricsServer().Serve(tls.NewListener(ln, p.metricsTLS))
+}
@@ -0,0 +1,21 @@
+package fasthttpprometheus
+
+import "sync/atomic"
+
+// Disable stops recording the requests served by the instrumented handlers,
+// which are still served, e.g. to stop the growth of the series during a
+// cardinality incident. It applies to the scoped children of p as well.
+func (p *Prometheus) Disable() {
+	atomic.StoreInt32(p.disabled, 1)
+}
+
+// Enable resumes recording the requests after Disable.
+func (p *Prometheus) Enable() {
+	atomic.StoreInt32(p.disabled, 0)
+}
+
+// Enabled reports whether the requests are recorded, true unless Disable
+// was called.
+func (p *Prometheus) Enabled() bool {
+	return atomic.LoadInt32(p.disabled) == 0
+}
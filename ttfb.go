@@ -0,0 +1,41 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TimeToFirstByte is an option which enables the response_ttfb_seconds histogram of the time from the start of request
+// handling until the response headers are sent, by method and endpoint. For responses streamed with SetBodyStream or
+// SetBodyStreamWriter, it is the time until the server starts reading the body stream, otherwise the time the
+// handler took
+func TimeToFirstByte() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.ttfb = true
+	}
+}
+
+// observeTimeToFirstByte observes the time to first byte in stats.
+func (p *Prometheus) observeTimeToFirstByte(stats RequestStats) {
+	if p.ttfbDur == nil {
+		return
+	}
+
+	p.ttfbDur.WithLabelValues(stats.Method, stats.Endpoint).Observe(p.durationValue(stats.TimeToFirstByte))
+	p.trackSeries("response_ttfb_seconds", stats.Method, stats.Endpoint)
+}
+
+func (p *Prometheus) ttfbCollector() prometheus.Collector {
+	p.ttfbDur = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("response_ttfb_seconds"),
+			Help:        "The time until the HTTP response headers are sent in " + p.durationUnit.String() + ".",
+			Buckets:     p.requestDurationBuckets(),
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	return p.ttfbDur
+}
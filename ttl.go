@@ -0,0 +1,184 @@
+package fasthttpprometheus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// seriesExpiry keeps track of when the series of the request counter and
+// duration histogram were last observed, to delete the stale ones.
+type seriesExpiry struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[uint64][]*expiryEntry // hashLabelValues -> entries
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type expiryEntry struct {
+	lvs  []string
+	last int64 // unix nanoseconds
+}
+
+// minSeriesTTL is the shortest SeriesTTL: the series must live for a few
+// scrapes, and the janitor ticks every ttl/2.
+const minSeriesTTL = time.Second
+
+// SeriesTTL is an option which deletes the series of the request counter and duration histogram not observed within ttl,
+// which must be at least a second
+func SeriesTTL(ttl time.Duration) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.expiry = &seriesExpiry{ttl: ttl, entries: map[uint64][]*expiryEntry{}}
+	}
+}
+
+// touchSeries records that the series with the label values lvs was just
+// observed, returning its entry to be touched directly by the later requests.
+// It is a no-op unless the SeriesTTL option is set.
+func (p *Prometheus) touchSeries(lvs []string) *expiryEntry {
+	e := p.expiry
+	if e == nil {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	h := hashLabelValues(lvs)
+	e.mu.RLock()
+	entry := findExpiryEntry(e.entries[h], lvs)
+	e.mu.RUnlock()
+
+	if entry == nil {
+		e.mu.Lock()
+		if entry = findExpiryEntry(e.entries[h], lvs); entry == nil {
+			entry = &expiryEntry{lvs: append([]string(nil), lvs...)}
+			e.entries[h] = append(e.entries[h], entry)
+		}
+		e.mu.Unlock()
+	}
+
+	entry.touch(now)
+	return entry
+}
+
+func (entry *expiryEntry) touch(now int64) {
+	atomic.StoreInt64(&entry.last, now)
+}
+
+func findExpiryEntry(bucket []*expiryEntry, lvs []string) *expiryEntry {
+	for _, entry := range bucket {
+		if equalLabelValues(entry.lvs, lvs) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// remove removes the entries for which drop returns true and returns them.
+func (e *seriesExpiry) remove(drop func(*expiryEntry) bool) []*expiryEntry {
+	var removed []*expiryEntry
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for h, bucket := range e.entries {
+		kept := bucket[:0]
+		for _, entry := range bucket {
+			if drop(entry) {
+				removed = append(removed, entry)
+			} else {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(e.entries, h)
+		} else {
+			e.entries[h] = kept
+		}
+	}
+
+	return removed
+}
+
+// expireSeries deletes the series last observed before now minus the TTL.
+func (p *Prometheus) expireSeries(now time.Time) {
+	deadline := now.Add(-p.expiry.ttl).UnixNano()
+
+	expired := p.expiry.remove(func(entry *expiryEntry) bool {
+		return atomic.LoadInt64(&entry.last) < deadline
+	})
+	if len(expired) == 0 {
+		return
+	}
+	defer p.children.clear()
+
+	for _, entry := range expired {
+		p.reqCnt.DeleteLabelValues(entry.lvs...)
+		p.durationVec(entry.lvs[2]).DeleteLabelValues(entry.lvs...)
+		p.forgetSeries("requests_total", entry.lvs...)
+		p.forgetSeries("request_duration_seconds", entry.lvs...)
+	}
+
+	if p.endpointLimiter != nil {
+		p.forgetExpiredEndpoints(expired)
+	}
+}
+
+// forgetExpiredEndpoints forgets the endpoints of the expired entries in the
+// MaxEndpoints limiter, unless they still have series.
+func (p *Prometheus) forgetExpiredEndpoints(expired []*expiryEntry) {
+	live := map[string]bool{}
+	p.expiry.mu.RLock()
+	for _, bucket := range p.expiry.entries {
+		for _, entry := range bucket {
+			live[entry.lvs[2]] = true
+		}
+	}
+	p.expiry.mu.RUnlock()
+
+	for _, entry := range expired {
+		if endpoint := entry.lvs[2]; !live[endpoint] {
+			p.endpointLimiter.forget(endpoint)
+		}
+	}
+}
+
+// startExpiry starts the janitor deleting stale series, if configured.
+func (p *Prometheus) startExpiry() {
+	e := p.expiry
+	if e == nil {
+		return
+	}
+
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.ttl / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				p.expireSeries(now)
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopExpiry stops the janitor started by startExpiry.
+func (p *Prometheus) stopExpiry() {
+	e := p.expiry
+	if e == nil {
+		return
+	}
+
+	e.stopOnce.Do(func() { close(e.stop) })
+	<-e.done
+}
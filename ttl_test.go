@@ -0,0 +1,25 @@
+package fasthttpprometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSeriesTTLMinimum(t *testing.T) {
+	for _, ttl := range []time.Duration{-time.Second, 0, time.Nanosecond, time.Second - 1} {
+		if _, err := NewPrometheusWithError(Registry(prometheus.NewRegistry()), SeriesTTL(ttl)); err == nil {
+			t.Errorf("SeriesTTL(%v): no error", ttl)
+		}
+	}
+
+	p, err := NewPrometheusWithError(Registry(prometheus.NewRegistry()), SeriesTTL(time.Second))
+	if err != nil {
+		t.Fatalf("SeriesTTL(1s): %v", err)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
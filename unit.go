@@ -0,0 +1,62 @@
+package fasthttpprometheus
+
+import (
+	"strings"
+	"time"
+)
+
+// Unit is the unit of the recorded durations.
+type Unit int
+
+const (
+	// Seconds records durations in seconds, the Prometheus base unit.
+	Seconds Unit = iota
+	// Milliseconds records durations in milliseconds.
+	Milliseconds
+)
+
+func (u Unit) String() string {
+	if u == Milliseconds {
+		return "milliseconds"
+	}
+	return "seconds"
+}
+
+// DurationUnit is an option which sets the unit of the recorded durations, along with the suffix of the duration metric
+// names, e.g. request_duration_milliseconds. The buckets set with Buckets are in this unit
+func DurationUnit(unit Unit) func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.durationUnit = unit
+	}
+}
+
+// unitName returns the default metric name name with the suffix of the
+// duration unit.
+func (p *Prometheus) unitName(name string) string {
+	if p.durationUnit == Milliseconds && strings.HasSuffix(name, "_seconds") {
+		return strings.TrimSuffix(name, "_seconds") + "_milliseconds"
+	}
+	return name
+}
+
+// durationValue returns d in the duration unit.
+func (p *Prometheus) durationValue(d time.Duration) float64 {
+	if p.durationUnit == Milliseconds {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return d.Seconds()
+}
+
+// durationBuckets returns the default duration buckets in seconds converted
+// to the duration unit.
+func (p *Prometheus) durationBuckets(seconds []float64) []float64 {
+	if p.durationUnit != Milliseconds {
+		return seconds
+	}
+
+	buckets := make([]float64, len(seconds))
+	for i, b := range seconds {
+		buckets[i] = b * 1000
+	}
+	return buckets
+}
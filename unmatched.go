@@ -0,0 +1,49 @@
+package fasthttpprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// UnmatchedRequests is an option which counts the requests matching no route of the router, e.g. answered with
+// 404 or 405, in unmatched_requests_total instead of the endpoint labeled metrics, so arbitrary paths do not
+// create series. Without a known router, the requests answered with 404 or 405 are counted as unmatched
+func UnmatchedRequests() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.unmatchedRequests = true
+	}
+}
+
+// observeUnmatched counts the request in ctx in unmatched_requests_total and
+// reports whether it matched no route, with the UnmatchedRequests option.
+func (p *Prometheus) observeUnmatched(ctx *fasthttp.RequestCtx, rt routing, method string, code int) bool {
+	if !p.unmatchedRequests {
+		return false
+	}
+
+	if rt != nil {
+		if !rt.unmatched(ctx) {
+			return false
+		}
+	} else if !unmatchedStatus(code) {
+		return false
+	}
+
+	p.unmatched.WithLabelValues(method, p.statusLabel(code)).Inc()
+	return true
+}
+
+func (p *Prometheus) unmatchedCollector() prometheus.Collector {
+	p.unmatched = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   p.namespace,
+			Subsystem:   p.subsystem,
+			ConstLabels: p.constLabels,
+			Name:        p.name("unmatched_requests_total"),
+			Help:        "The HTTP requests matching no route.",
+		},
+		[]string{"method", "code"},
+	)
+
+	return p.unmatched
+}
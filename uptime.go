@@ -0,0 +1,40 @@
+package fasthttpprometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Uptime is an option which registers the start_time_seconds gauge, the time the middleware was created since the Unix
+// epoch, and the uptime_seconds counter, the time elapsed since then, so restarts are visible without the process collector
+func Uptime() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.uptime = true
+	}
+}
+
+func (p *Prometheus) uptimeCollectors() []prometheus.Collector {
+	start := time.Now()
+
+	startTime := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("start_time_seconds"),
+		Help:        "The start time of the middleware since the Unix epoch in " + p.durationUnit.String() + ".",
+	})
+	startTime.Set(p.durationValue(time.Duration(start.UnixNano())))
+
+	uptime := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("uptime_seconds"),
+		Help:        "The time elapsed since the start of the middleware in " + p.durationUnit.String() + ".",
+	}, func() float64 {
+		return p.durationValue(time.Since(start))
+	})
+
+	return []prometheus.Collector{startTime, uptime}
+}
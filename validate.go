@@ -0,0 +1,174 @@
+package fasthttpprometheus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// validate checks the configuration set by the options.
+func (p *Prometheus) validate() error {
+	if len(p.optionErrs) > 0 {
+		return p.optionErrs[0]
+	}
+
+	if err := p.validateNames(); err != nil {
+		return err
+	}
+
+	if err := p.validateMetricsPaths(); err != nil {
+		return err
+	}
+
+	if p.buckets != nil {
+		if err := validateBuckets(p.buckets); err != nil {
+			return err
+		}
+	}
+
+	if err := p.validateEndpointBuckets(); err != nil {
+		return err
+	}
+
+	if p.reqSizeBuckets != nil {
+		if err := validateBuckets(p.sizeBucketsOr(p.reqSizeBuckets)); err != nil {
+			return err
+		}
+	}
+
+	if p.respSizeBuckets != nil {
+		if err := validateBuckets(p.sizeBucketsOr(p.respSizeBuckets)); err != nil {
+			return err
+		}
+	}
+
+	if p.slow != nil && p.slow.threshold <= 0 {
+		return fmt.Errorf("fasthttpprometheus: SlowRequestThreshold %v is not positive", p.slow.threshold)
+	}
+
+	if err := p.apdex.validate(); err != nil {
+		return err
+	}
+
+	if err := p.latencySLO.validate(); err != nil {
+		return err
+	}
+
+	if err := p.summary.validate(); err != nil {
+		return err
+	}
+
+	if err := p.nativeHistogram.validate(); err != nil {
+		return err
+	}
+
+	if err := p.multiprocess.validate(); err != nil {
+		return err
+	}
+
+	if pi := p.preInit; pi != nil {
+		n := len(pi.codes) * len(pi.methods) * len(pi.endpoints)
+		if n > maxPreInitializedSeries {
+			return fmt.Errorf("fasthttpprometheus: PreInitialize would create %d series, more than the limit of %d", n, maxPreInitializedSeries)
+		}
+		if pi.router != nil {
+			series, err := p.listRouteSeries(pi.router)
+			if err != nil {
+				return err
+			}
+			pi.routeSeries = series
+		}
+	}
+
+	if pg := p.pushGateway; pg != nil && pg.interval < 0 {
+		return fmt.Errorf("fasthttpprometheus: PushGateway interval %v is negative", pg.interval)
+	}
+
+	if p.expiry != nil && p.expiry.ttl < minSeriesTTL {
+		return fmt.Errorf("fasthttpprometheus: SeriesTTL %v is shorter than %v", p.expiry.ttl, minSeriesTTL)
+	}
+
+	if err := p.validateExtraLabels(); err != nil {
+		return err
+	}
+
+	if err := validateExpvar(p.expvarName); err != nil {
+		return err
+	}
+
+	if p.allowlist != nil {
+		if err := p.allowlist.parse(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNames checks that the namespace, subsystem, const labels and the
+// names returned by Naming form valid metric and label names, which would
+// otherwise only fail when the metrics are registered.
+func (p *Prometheus) validateNames() error {
+	if p.namespace != "" && !model.IsValidMetricName(model.LabelValue(p.namespace)) {
+		return fmt.Errorf("fasthttpprometheus: invalid Namespace %q", p.namespace)
+	}
+	if p.subsystem != "" && !model.IsValidMetricName(model.LabelValue(p.subsystem)) {
+		return fmt.Errorf("fasthttpprometheus: invalid Subsystem %q", p.subsystem)
+	}
+
+	for name, value := range p.constLabels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return fmt.Errorf("fasthttpprometheus: invalid const label name %q", name)
+		}
+		if !model.LabelValue(value).IsValid() {
+			return fmt.Errorf("fasthttpprometheus: invalid value %q of const label %q", value, name)
+		}
+	}
+
+	if p.naming != nil {
+		for _, name := range []string{"requests_total", "request_duration_seconds", "concurrent_requests"} {
+			renamed := p.name(name)
+			if !model.IsValidMetricName(model.LabelValue(renamed)) {
+				return fmt.Errorf("fasthttpprometheus: Naming renames %s to the invalid metric name %q", name, renamed)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateMetricsPaths checks that the metrics paths are absolute and unique.
+func (p *Prometheus) validateMetricsPaths() error {
+	seen := map[string]bool{}
+	for _, path := range p.metricsPaths() {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("fasthttpprometheus: metrics path %q does not start with /", path)
+		}
+		if seen[path] {
+			return fmt.Errorf("fasthttpprometheus: metrics path %q is given twice", path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+// validateBuckets checks that buckets are not negative and sorted in increasing
+// order. A zero bucket counts the observations of zero, like the requests
+// faster than the clock resolution or without a body.
+func validateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("fasthttpprometheus: no buckets given")
+	}
+
+	for i, b := range buckets {
+		if b < 0 {
+			return fmt.Errorf("fasthttpprometheus: bucket %v is negative", b)
+		}
+		if i > 0 && b <= buckets[i-1] {
+			return fmt.Errorf("fasthttpprometheus: buckets are not sorted in increasing order: %v follows %v", b, buckets[i-1])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package fasthttpprometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+)
+
+// Websockets is an option which excludes the requests upgrading their connection, answered with 101 and hijacked, as
+// websocket upgraders do, from the request metrics, counting them in websocket_upgrades_total instead. On listeners
+// instrumented with InstrumentListener, the upgraded connections are also tracked until they are closed, in the
+// open_websockets gauge and the websocket_duration_seconds histogram
+func Websockets() func(*Prometheus) {
+	return func(p *Prometheus) {
+		p.websockets = &websocketMetrics{}
+	}
+}
+
+type websocketMetrics struct {
+	upgrades prometheus.Counter
+	open     prometheus.Gauge
+	dur      prometheus.Histogram
+}
+
+// observeUpgrade tracks the request in ctx and reports whether it upgraded
+// its connection, with the Websockets option.
+func (p *Prometheus) observeUpgrade(ctx *fasthttp.RequestCtx) bool {
+	ws := p.websockets
+	if ws == nil || ctx.Response.StatusCode() != fasthttp.StatusSwitchingProtocols || !ctx.Hijacked() {
+		return false
+	}
+
+	ws.upgrades.Inc()
+
+	var c *instrumentedConn
+	switch conn := ctx.Conn().(type) {
+	case *instrumentedConn:
+		c = conn
+	case *instrumentedTLSConn:
+		c = conn.instrumentedConn
+	default:
+		return true
+	}
+
+	ws.open.Inc()
+	start := time.Now()
+	c.onClose(func() {
+		ws.open.Dec()
+		ws.dur.Observe(p.durationValue(time.Since(start)))
+	})
+
+	return true
+}
+
+func (p *Prometheus) websocketCollectors() []prometheus.Collector {
+	ws := p.websockets
+
+	ws.upgrades = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("websocket_upgrades_total"),
+		Help:        "The HTTP requests upgrading their connection.",
+	})
+
+	ws.open = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("open_websockets"),
+		Help:        "Number of open upgraded connections.",
+	})
+
+	ws.dur = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   p.namespace,
+		Subsystem:   p.subsystem,
+		ConstLabels: p.constLabels,
+		Name:        p.name("websocket_duration_seconds"),
+		Help:        "The lifetime of the upgraded connections in " + p.durationUnit.String() + ".",
+		Buckets:     p.durationBuckets([]float64{1, 10, 60, 300, 900, 1800, 3600, 4 * 3600, 12 * 3600, 24 * 3600}),
+	})
+
+	return []prometheus.Collector{ws.upgrades, ws.open, ws.dur}
+}
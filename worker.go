@@ -0,0 +1,27 @@
+package fasthttpprometheus
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WorkerLabel is an option which adds the worker const label set to id to every metric, or to the process id if id is
+// empty, so the series of the processes of a prefork deployment are distinct and can be summed with PromQL
+func WorkerLabel(id string) func(*Prometheus) {
+	return func(p *Prometheus) {
+		if id == "" {
+			id = strconv.Itoa(os.Getpid())
+		}
+		p.worker = id
+	}
+}
+
+// applyWorkerLabel adds the worker label of WorkerLabel to the const labels,
+// once all the options are applied so ConstLabels does not override it.
+func (p *Prometheus) applyWorkerLabel() {
+	if p.worker != "" {
+		p.constLabels = p.constLabelsWith(prometheus.Labels{"worker": p.worker})
+	}
+}